@@ -1,12 +1,15 @@
 package main
 
 import (
+	"fmt"
 	"image/color"
 	"log"
 	"math"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
@@ -17,6 +20,17 @@ const (
 	centerX      = screenWidth / 2
 	centerY      = screenHeight / 2
 	gridSize     = 4
+
+	// Past these ages a source's waves are below negligibleHeight everywhere,
+	// so they're skipped without sampling
+	negligibleHeight   = 1e-3
+	outgoingDecayTime  = 300.0
+	reflectedDecayTime = 350.0
+)
+
+var (
+	maxOutgoingAge  = outgoingDecayTime * math.Log(1.5/negligibleHeight)
+	maxReflectedAge = reflectedDecayTime * math.Log(1.5*0.7/negligibleHeight)
 )
 
 type WaveSource struct {
@@ -28,6 +42,8 @@ type Game struct {
 	waveSources []WaveSource
 	pressed     bool
 	frame       int
+	fast        bool          // skip the reflections for a quick preview
+	fieldTime   time.Duration // time the last frame spent computing heights
 }
 
 func (g *Game) Update() error {
@@ -50,6 +66,10 @@ func (g *Game) Update() error {
 		g.pressed = false
 	}
 
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		g.fast = !g.fast
+	}
+
 	g.frame++
 	return nil
 }
@@ -61,6 +81,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	vector.StrokeCircle(screen, float32(centerX), float32(centerY), float32(shapeRadius), 2, color.RGBA{100, 150, 200, 255}, false)
 
 	// Draw grid of points
+	start := time.Now()
 	for xi := int(centerX - float64(shapeRadius)); xi < int(centerX+float64(shapeRadius)); xi += gridSize {
 		for yi := int(centerY - float64(shapeRadius)); yi < int(centerY+float64(shapeRadius)); yi += gridSize {
 			px := float64(xi)
@@ -99,7 +120,13 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		}
 	}
 
-	ebitenutil.DebugPrint(screen, "Click inside the circle to create waves")
+	g.fieldTime = time.Since(start)
+
+	reflections := "on"
+	if g.fast {
+		reflections = "off"
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Click inside the circle to create waves\nF toggles reflections (%s) | field %.1f ms", reflections, float64(g.fieldTime.Microseconds())/1000))
 }
 
 func (g *Game) calculateWaveHeight(x, y float64) float64 {
@@ -136,6 +163,9 @@ func (g *Game) calculateOutgoingWave(x, y float64, source WaveSource) float64 {
 	wavelength := 40.0
 	amplitude := 1.5
 	timeElapsed := float64(g.frame - source.createdAt)
+	if timeElapsed > maxOutgoingAge {
+		return 0.0
+	}
 	waveFront := waveSpeed * timeElapsed
 
 	if distFromSource < waveFront {
@@ -146,7 +176,7 @@ func (g *Game) calculateOutgoingWave(x, y float64, source WaveSource) float64 {
 			envelope := math.Exp(-(distanceFromFront * distanceFromFront) / (waveInfluence * waveInfluence))
 			phase := (distFromSource / wavelength) * 2 * math.Pi
 			wave := amplitude * math.Sin(phase) * envelope
-			damping := math.Exp(-timeElapsed / 300)
+			damping := math.Exp(-timeElapsed / outgoingDecayTime)
 			return wave * damping
 		}
 	}
@@ -154,13 +184,16 @@ func (g *Game) calculateOutgoingWave(x, y float64, source WaveSource) float64 {
 }
 
 func (g *Game) calculateReflectedWaves(x, y float64, source WaveSource) float64 {
+	if g.fast {
+		return 0.0
+	}
 	waveSpeed := 1.5
 	wavelength := 40.0
 	amplitude := 1.5
 	timeElapsed := float64(g.frame - source.createdAt)
 
 	// Only calculate reflections if enough time has passed
-	if timeElapsed < 50 {
+	if timeElapsed < 50 || timeElapsed > maxReflectedAge {
 		return 0.0
 	}
 
@@ -213,7 +246,7 @@ func (g *Game) calculateReflectedWaves(x, y float64, source WaveSource) float64
 					envelope := math.Exp(-(distanceFromReflectedFront * distanceFromReflectedFront) / (waveInfluence * waveInfluence))
 					phase := (distFromReflectionPoint / wavelength) * 2 * math.Pi
 					wave := amplitude * math.Sin(phase) * envelope * 0.7
-					damping := math.Exp(-timeElapsed / 350)
+					damping := math.Exp(-timeElapsed / reflectedDecayTime)
 					totalReflectedHeight += wave * damping
 				}
 			}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+// Benchmarks of the reflections toggle variant. Like it, this file is
+// archived out of the build by its leading underscore, so copy both into a
+// package of the module to run them, from the repository root:
+//
+//	mkdir x10 && cp _mainx10.go x10/main.go && cp _mainx10_test.go x10/main_test.go
+//	go test -bench . ./x10
+//
+// and remove x10 afterwards.
+
+// BenchmarkField times computing every height of a frame with a few sources
+// whose reflections have formed, with the reflections on and with them off.
+func BenchmarkField(b *testing.B) {
+	field := func(b *testing.B, fast bool) {
+		g := &Game{fast: fast, frame: 200}
+		for _, p := range [][2]float64{{centerX - 60, centerY}, {centerX + 50, centerY + 40}, {centerX, centerY - 90}} {
+			g.waveSources = append(g.waveSources, WaveSource{p[0], p[1], 0})
+		}
+		for b.Loop() {
+			for xi := int(centerX - float64(shapeRadius)); xi < int(centerX+float64(shapeRadius)); xi += gridSize {
+				for yi := int(centerY - float64(shapeRadius)); yi < int(centerY+float64(shapeRadius)); yi += gridSize {
+					g.calculateWaveHeight(float64(xi), float64(yi))
+				}
+			}
+		}
+	}
+	b.Run("reflections", func(b *testing.B) { field(b, false) })
+	b.Run("fast", func(b *testing.B) { field(b, true) })
+}
@@ -5,29 +5,25 @@ import (
 	"image/color"
 	"math"
 
+	"game/spring"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
 	screenWidth  = 1200
 	screenHeight = 800
-	waveDamping  = 0.995
 	waveSpeed    = 2.0
 )
 
-type Particle struct {
-	x, y    float64
-	vx, vy  float64
-	origX   float64
-	origY   float64
-	onShape bool
-}
-
+// Wave is the ring of particles along the pond outline, see spring.Ring. D
+// toggles its damping.
 type Wave struct {
-	particles []*Particle
-	shape     []Vector2
+	spring.Ring
+	shape []Vector2
 }
 
 type Vector2 struct {
@@ -36,8 +32,8 @@ type Vector2 struct {
 
 func NewWave() *Wave {
 	w := &Wave{
-		particles: make([]*Particle, 0),
-		shape:     generateCircleShape(screenWidth/2, screenHeight/2, 150),
+		Ring:  spring.Ring{Particles: make([]*spring.Particle, 0), Damped: true},
+		shape: generateCircleShape(screenWidth/2, screenHeight/2, 150),
 	}
 	w.initializeParticles()
 	return w
@@ -57,25 +53,25 @@ func generateCircleShape(cx, cy, radius float64) []Vector2 {
 
 func (w *Wave) initializeParticles() {
 	for _, point := range w.shape {
-		p := &Particle{
-			x:       point.x,
-			y:       point.y,
-			origX:   point.x,
-			origY:   point.y,
-			vx:      0,
-			vy:      0,
-			onShape: true,
+		p := &spring.Particle{
+			X:       point.x,
+			Y:       point.y,
+			OrigX:   point.x,
+			OrigY:   point.y,
+			VX:      0,
+			VY:      0,
+			OnShape: true,
 		}
-		w.particles = append(w.particles, p)
+		w.Particles = append(w.Particles, p)
 	}
 }
 
 func (w *Wave) addWaveAtMouse(mx, my float64) {
 	// Find closest particle to mouse click
 	minDist := math.MaxFloat64
-	var closestP *Particle
-	for _, p := range w.particles {
-		dist := math.Sqrt((p.x-mx)*(p.x-mx) + (p.y-my)*(p.y-my))
+	var closestP *spring.Particle
+	for _, p := range w.Particles {
+		dist := math.Sqrt((p.X-mx)*(p.X-mx) + (p.Y-my)*(p.Y-my))
 		if dist < minDist {
 			minDist = dist
 			closestP = p
@@ -84,46 +80,7 @@ func (w *Wave) addWaveAtMouse(mx, my float64) {
 
 	if closestP != nil && minDist < 100 {
 		// Apply impulse to create wave
-		closestP.vy -= 15
-	}
-}
-
-func (w *Wave) update() {
-	// Update particle velocities and positions
-	for i, p := range w.particles {
-		if !p.onShape {
-			continue
-		}
-
-		// Apply velocity
-		p.x += p.vx
-		p.y += p.vy
-
-		// Spring force back to original position
-		dx := p.origX - p.x
-		dy := p.origY - p.y
-		springForce := 0.05
-		p.vx += dx * springForce
-		p.vy += dy * springForce
-
-		// Damping
-		p.vx *= waveDamping
-		p.vy *= waveDamping
-
-		// Propagate wave to neighbors
-		// neighborDist := 15.0
-		leftIdx := (i - 1 + len(w.particles)) % len(w.particles)
-		rightIdx := (i + 1) % len(w.particles)
-
-		leftP := w.particles[leftIdx]
-		rightP := w.particles[rightIdx]
-
-		// Wave propagation
-		if math.Abs(p.y-p.origY) < 100 { // Only propagate if not too large
-			spread := 0.2
-			p.vy += spread * (leftP.y - p.y)
-			p.vy += spread * (rightP.y - p.y)
-		}
+		closestP.VY -= 15
 	}
 }
 
@@ -132,22 +89,22 @@ func (w *Wave) draw(screen *ebiten.Image) {
 
 	// Draw the shape
 	if len(w.shape) > 1 {
-		for i := 0; i < len(w.particles)-1; i++ {
-			p1 := w.particles[i]
-			p2 := w.particles[i+1]
-			vector.StrokeLine(screen, float32(p1.x), float32(p1.y), float32(p2.x), float32(p2.y), float32(2), color.RGBA{100, 200, 255, 255}, false)
+		for i := 0; i < len(w.Particles)-1; i++ {
+			p1 := w.Particles[i]
+			p2 := w.Particles[i+1]
+			vector.StrokeLine(screen, float32(p1.X), float32(p1.Y), float32(p2.X), float32(p2.Y), float32(2), color.RGBA{100, 200, 255, 255}, false)
 		}
 		// Close the shape
-		p1 := w.particles[len(w.particles)-1]
-		p2 := w.particles[0]
-		vector.StrokeLine(screen, float32(p1.x), float32(p1.y), float32(p2.x), float32(p2.y), float32(2), color.RGBA{100, 200, 255, 255}, false)
+		p1 := w.Particles[len(w.Particles)-1]
+		p2 := w.Particles[0]
+		vector.StrokeLine(screen, float32(p1.X), float32(p1.Y), float32(p2.X), float32(p2.Y), float32(2), color.RGBA{100, 200, 255, 255}, false)
 	}
 
 	// Draw particles
-	for _, p := range w.particles {
-		offsetFromOriginal := math.Abs(p.y - p.origY)
+	for _, p := range w.Particles {
+		offsetFromOriginal := math.Abs(p.Y - p.OrigY)
 		intensity := uint8(math.Min(255, offsetFromOriginal*2))
-		vector.DrawFilledCircle(screen, float32(p.x), float32(p.y), float32(3), color.RGBA{100 + intensity, 150, 255, 255}, false)
+		vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), float32(3), color.RGBA{100 + intensity, 150, 255, 255}, false)
 	}
 }
 
@@ -168,13 +125,17 @@ func (g *Game) Update() error {
 		g.wave.addWaveAtMouse(float64(x), float64(y))
 	}
 
-	g.wave.update()
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		g.wave.Damped = !g.wave.Damped
+	}
+
+	g.wave.Update()
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.wave.draw(screen)
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %.2f\nClick on the shape to create waves", ebiten.CurrentTPS()))
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %.2f\nClick on the shape to create waves\nEnergy: %.1f | D to toggle damping (%v)", ebiten.CurrentTPS(), g.wave.Energy(), g.wave.Damped))
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
@@ -70,19 +70,19 @@ func (g *Game) Draw(screen *ebiten.Image) {
 			if distFromCenter < shapeRadius {
 				height := g.calculateWaveHeight(float64(x), float64(y))
 
-				// Map height to color - smooth gradient
-				normalizedHeight := height / 1.5
-				if normalizedHeight > 0 {
+				// Map height to color, the same way as the other variants
+				var r, g_c, b uint8
+				if height > 0 {
 					// Positive: light blue
-					intensity := uint8(normalizedHeight * 200)
+					intensity := uint8(math.Min(height*200, 255))
 					r = 100
-					g_c = 150 + intensity/2
+					g_c = uint8(150 + int(intensity)/2)
 					b = 220
 				} else {
 					// Negative: light orange/red
-					intensity := uint8(-normalizedHeight * 200)
+					intensity := uint8(math.Min(-height*200, 255))
 					r = 220
-					g_c = 150 - intensity/2
+					g_c = uint8(150 - int(intensity)/2)
 					b = 100
 				}
 
@@ -95,7 +95,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		}
 	}
 
-	ebitenutil.DebugPrintString(screen, "Click inside the circle to create waves")
+	ebitenutil.DebugPrint(screen, "Click inside the circle to create waves")
 }
 
 func (g *Game) calculateWaveHeight(x, y float64) float64 {
@@ -110,6 +110,7 @@ func (g *Game) calculateWaveHeight(x, y float64) float64 {
 		waveSpeed := 1.5
 		wavelength := 40.0
 		amplitude := 1.5
+		waveInfluence := 30.0 // only oscillate this close to a wave front
 
 		// Time elapsed since wave source created
 		timeElapsed := float64(g.frame - source.createdAt)
@@ -122,9 +123,7 @@ func (g *Game) calculateWaveHeight(x, y float64) float64 {
 
 		// Only create waves after they've started propagating
 		if distFromSource < waveFront {
-			// Create wave oscillation
-			// Only oscillate near the wave front
-			waveInfluence := 30.0
+			// Create wave oscillation near the wave front
 			if math.Abs(distanceFromFront) < waveInfluence {
 				// Gaussian envelope to smooth the wave
 				envelope := math.Exp(-(distanceFromFront * distanceFromFront) / (waveInfluence * waveInfluence))
@@ -0,0 +1,961 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"math/cmplx"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"game/wavegrid"
+)
+
+const (
+	analyticGridSize   = 4
+	finiteSourceRadius = 12.0 // radius of sources created with Shift+click
+	sourceDiskSamples  = 6    // ring samples used to approximate a finite-radius source
+
+	defaultWavelength = 40.0
+	defaultAmplitude  = 1.5
+	swellWavelength   = 80.0 // right click creates a long, strong swell
+	swellAmplitude    = 2.5
+
+	analyticTickTime  = 1.0 / 60 // real seconds per tick of the frame clock
+	analyticWaveSpeed = 1.5      // pixels per tick, at defaultWavelength when dispersive
+
+	// With dispersion on, pulses carry a second, longer component that
+	// outruns the first, so the packet visibly spreads out
+	dispersionWavelengthRatio = 2.0
+
+	maxAnalyticHeight = 1.5 // summed heights are clamped to ±this
+
+	dragEmitInterval = 6 // frames between sources emitted while dragging
+
+	// sourceWarning is the share of cfg.MaxSources from which the source
+	// count in the overlay turns red, before the oldest start disappearing
+	sourceWarning = 0.9
+
+	// sourceDecayCutoff is the damped amplitude below which a source no longer
+	// visibly contributes and is removed
+	sourceDecayCutoff = 0.01
+
+	// Two-source interference demo, toggled with I
+	continuousAmplitude     = 0.75 // keeps the sum of the pair inside the height clamp
+	defaultSourceSeparation = 80.0
+	minSourceSeparation     = 10.0
+	sourceSeparationStep    = 10.0
+	relativePhaseStep       = math.Pi / 8
+
+	maxDriftSpeed = 1.2 // pixels per tick, below the wave speed so fronts stay ahead
+
+	sourceGrabRadius = 10.0 // pixels around a source that a press picks it up from
+
+	defaultReflectivity = 0.7 // share of a wave the boundary reflects
+
+	maxBounces = 8 // echoes per pulse B steps up to, each adding two image sources per point
+
+	// Each new source's hue turns by the golden angle, so consecutive sources
+	// never look alike however many there are
+	sourceHueStep = 137.5
+)
+
+type WaveSource struct {
+	x, y         float64
+	createdAt    int
+	sourceRadius float64 // 0 means an ideal point source
+	amplitude    float64
+	wavelength   float64
+	phase        float64 // offset added to the phase of the emitted wave, in radians
+	continuous   bool    // emits a steady wave train that doesn't decay, instead of a pulse
+	wavelength2  float64 // second component of a dispersive pulse, 0 for none
+	hue          float64 // degrees, tints this source's ripples when tinting is on
+	packet       bool    // emits a Gaussian wave packet, see packetWave
+	vx, vy       float64 // drift in pixels per tick, the source bounces off the boundary
+}
+
+// AnalyticGame computes the wave height at each point directly from the list
+// of sources instead of integrating a grid.
+type AnalyticGame struct {
+	cfg         Config
+	cx, cy      float64 // center of the circular boundary
+	shapeRadius float64
+	shape       wavegrid.Shape // the boundary as a polygon, for click tests
+	colorMap    ColorMap
+	clampMode   ClampMode // how summed heights are kept within ±maxAnalyticHeight
+
+	reflectivity float64 // scales the reflected waves, 0 to 1
+	dispersion   bool    // wave speed depends on wavelength, see waveSpeed
+	steadyState  bool    // draw the settled amplitude of the continuous sources instead
+	tinted       bool    // color each point by the hues of the sources moving it
+	showFronts   bool    // stroke the circle each source's front has reached, see drawFronts
+	nextHue      float64
+	components   []float64 // reused buffer for waveComponents
+	waveSources  []WaveSource
+	pressed      bool
+	frame        int
+	paused       bool
+
+	timestep fixedTimestep
+
+	lastEmitFrame        int
+	lastEmitX, lastEmitY int
+	cursorX, cursorY     int // cursor position in the previous Update, for Ctrl+drag
+
+	screenshotRequested bool // Draw saves the next frame it renders
+
+	energy          *ringBuffer // total energy per tick, for the graph
+	heightProbe     *HeightProbe
+	histogram       *Histogram       // nil while hidden
+	ruler           *Ruler           // nil while hidden
+	dragging        int              // index in waveSources of the source being moved, or -1
+	touchIDs        []ebiten.TouchID // reused buffer for new touches
+	lastEnergyFrame int
+
+	// interference places two continuous sources sourceSeparation apart across
+	// the center, the right one relativePhase radians ahead of the left
+	interference     bool
+	sourceSeparation float64
+	relativePhase    float64
+}
+
+func NewAnalyticGame(cfg Config) *AnalyticGame {
+	return &AnalyticGame{
+		cfg:         cfg,
+		cx:          float64(cfg.ScreenWidth) / 2,
+		cy:          float64(cfg.ScreenHeight) / 2,
+		shapeRadius: cfg.ShapeRadius,
+		shape:       wavegrid.Circle(float64(cfg.ScreenWidth)/2, float64(cfg.ScreenHeight)/2, cfg.ShapeRadius),
+		colorMap:    colorMaps[0],
+
+		reflectivity: defaultReflectivity,
+		timestep:     fixedTimestep{dt: analyticTickTime},
+		energy:       newRingBuffer(graphSamples),
+		heightProbe:  NewHeightProbe(),
+
+		sourceSeparation: defaultSourceSeparation,
+		dragging:         -1,
+	}
+}
+
+func (g *AnalyticGame) Update() error {
+	leftPressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	rightPressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+
+	// Dragging a ruler end takes over the left button until it's released
+	if g.ruler != nil {
+		x, y := ebiten.CursorPosition()
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			g.ruler.grab(float64(x), float64(y))
+		}
+		if !leftPressed {
+			g.ruler.release()
+		}
+		if g.ruler.dragging >= 0 {
+			g.ruler.move(float64(x), float64(y))
+			leftPressed = false
+		}
+	}
+
+	// Pressing near a source picks it up instead of emitting. It keeps its
+	// start time, so its rings stay the same age and just follow it around
+	if leftPressed && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		g.dragging = g.sourceAt(float64(x), float64(y))
+	}
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		g.dragging = -1
+	}
+	if g.dragging >= 0 {
+		x, y := ebiten.CursorPosition()
+		if s := &g.waveSources[g.dragging]; g.shape.Contains(float64(x), float64(y)) {
+			s.x, s.y = float64(x), float64(y)
+			s.vx, s.vy = 0, 0
+		}
+		leftPressed = false
+	}
+
+	if leftPressed || rightPressed {
+		mx, my := ebiten.CursorPosition()
+		// A new press always emits; dragging keeps emitting along the cursor
+		// path every dragEmitInterval frames, leaving a Doppler-like wake
+		moved := mx != g.lastEmitX || my != g.lastEmitY
+		if !g.pressed || (moved && g.frame-g.lastEmitFrame >= dragEmitInterval) {
+			g.emitSource(mx, my, rightPressed)
+		}
+		g.pressed = true
+	} else {
+		g.pressed = false
+	}
+	// Every new touch emits a source of its own, so several fingers make several ripples
+	g.touchIDs = inpututil.AppendJustPressedTouchIDs(g.touchIDs[:0])
+	for _, id := range g.touchIDs {
+		x, y := ebiten.TouchPosition(id)
+		g.emitSource(x, y, false)
+	}
+	g.cursorX, g.cursorY = ebiten.CursorPosition()
+	g.heightProbe.follow(float64(g.cursorX), float64(g.cursorY))
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		g.waveSources = nil
+		g.interference = false
+		g.dragging = -1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+		g.ClearField()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyI) {
+		g.interference = !g.interference
+		g.placeInterferencePair()
+	}
+	if g.interference {
+		maxSeparation := 2 * g.shapeRadius * 0.9
+		changed := true
+		switch {
+		case inpututil.IsKeyJustPressed(ebiten.KeyEqual):
+			g.sourceSeparation = math.Min(maxSeparation, g.sourceSeparation+sourceSeparationStep)
+		case inpututil.IsKeyJustPressed(ebiten.KeyMinus):
+			g.sourceSeparation = math.Max(minSourceSeparation, g.sourceSeparation-sourceSeparationStep)
+		case inpututil.IsKeyJustPressed(ebiten.KeyBracketRight):
+			g.relativePhase = math.Mod(g.relativePhase+relativePhaseStep, 2*math.Pi)
+		case inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft):
+			g.relativePhase = math.Mod(g.relativePhase-relativePhaseStep+2*math.Pi, 2*math.Pi)
+		default:
+			changed = false
+		}
+		if changed {
+			g.placeInterferencePair()
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.paused = !g.paused
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		g.screenshotRequested = true
+	}
+	updateTheme()
+	if inpututil.IsKeyJustPressed(ebiten.KeyK) && !ebiten.IsKeyPressed(ebiten.KeyShift) {
+		g.colorMap = nextColorMap(g.colorMap)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		g.heightProbe.frozen = !g.heightProbe.frozen
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		g.dispersion = !g.dispersion
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		g.emitPacket(ebiten.CursorPosition())
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+		g.tinted = !g.tinted
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		g.showFronts = !g.showFronts
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		g.steadyState = !g.steadyState
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		g.clampMode = (g.clampMode + 1) % 2
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyY) {
+		if g.histogram == nil {
+			g.histogram = NewHistogram(histogramBins)
+		} else {
+			g.histogram = nil
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyU) {
+		if g.ruler == nil {
+			// Start two wavelengths long across the middle, ready to lay on a ripple
+			g.ruler = NewRuler(wavegrid.Vector2{X: g.cx - defaultWavelength, Y: g.cy}, wavegrid.Vector2{X: g.cx + defaultWavelength, Y: g.cy})
+		} else {
+			g.ruler = nil
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+		g.cfg.Bounces = g.cfg.Bounces%maxBounces + 1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPageUp) {
+		g.reflectivity = stepFraction(g.reflectivity, reflectivityStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPageDown) {
+		g.reflectivity = stepFraction(g.reflectivity, -reflectivityStep)
+	}
+
+	// The frame counter is the clock of the analytic model, so pausing just stops it
+	if !g.paused {
+		for range g.timestep.steps() {
+			g.stepOnce()
+		}
+	} else {
+		g.timestep.reset()
+		if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+			g.stepOnce()
+		}
+	}
+
+	g.pruneSources()
+	g.capSources()
+	return nil
+}
+
+// stepOnce advances the model clock by one tick of analyticTickTime.
+func (g *AnalyticGame) stepOnce() {
+	g.frame++
+	g.moveSources()
+	g.heightProbe.record(g.calculateWaveHeight(g.heightProbe.x, g.heightProbe.y))
+}
+
+// ClearField calms the water while keeping every source, which starts
+// emitting again from scratch as if just created.
+func (g *AnalyticGame) ClearField() {
+	for i := range g.waveSources {
+		g.waveSources[i].createdAt = g.frame
+	}
+}
+
+// moveSources advances drifting sources by one tick, bouncing them off the
+// boundary so they always stay inside it.
+func (g *AnalyticGame) moveSources() {
+	center := wavegrid.Vector2{X: g.cx, Y: g.cy}
+	for i := range g.waveSources {
+		s := &g.waveSources[i]
+		if s.vx == 0 && s.vy == 0 {
+			continue
+		}
+		pos := wavegrid.Vector2{X: s.x + s.vx, Y: s.y + s.vy}
+		if offset := pos.Sub(center); offset.Length() >= g.shapeRadius {
+			n := offset.Normalize()
+			v := wavegrid.Vector2{X: s.vx, Y: s.vy}.Reflect(n)
+			s.vx, s.vy = v.X, v.Y
+			pos = center.Add(n.Scale(g.shapeRadius - 1))
+		}
+		s.x, s.y = pos.X, pos.Y
+	}
+}
+
+// pruneSources drops sources that have decayed below sourceDecayCutoff, since
+// every remaining source is evaluated for every point each frame.
+func (g *AnalyticGame) pruneSources() {
+	g.keepSources(func(_ int, source WaveSource) bool {
+		timeElapsed := float64(g.frame - source.createdAt)
+		damping := math.Exp(-timeElapsed / 300)
+		return source.continuous || source.amplitude*damping >= sourceDecayCutoff
+	})
+}
+
+// keepSources drops the sources keep rejects, in place and in order, and
+// keeps dragging pointed at the same source.
+func (g *AnalyticGame) keepSources(keep func(i int, source WaveSource) bool) {
+	alive := g.waveSources[:0]
+	dragging := -1
+	for i, source := range g.waveSources {
+		if keep(i, source) {
+			if i == g.dragging {
+				dragging = len(alive)
+			}
+			alive = append(alive, source)
+		}
+	}
+	g.waveSources = alive
+	g.dragging = dragging
+}
+
+// sourceAt returns the index of the source nearest (x, y) within
+// sourceGrabRadius, or -1 if there is none.
+func (g *AnalyticGame) sourceAt(x, y float64) int {
+	found, best := -1, sourceGrabRadius
+	for i, source := range g.waveSources {
+		if d := math.Hypot(source.x-x, source.y-y); d <= best {
+			found, best = i, d
+		}
+	}
+	return found
+}
+
+// emitSource adds a wave source at the cursor if it is inside the boundary.
+func (g *AnalyticGame) emitSource(mx, my int, swell bool) {
+	g.lastEmitX, g.lastEmitY, g.lastEmitFrame = mx, my, g.frame
+
+	if p, ok := g.shape.SnapInside(float64(mx), float64(my), edgeTolerance); ok {
+		radius := 0.0
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			radius = finiteSourceRadius
+		}
+		amplitude, wavelength := defaultAmplitude, defaultWavelength
+		if swell {
+			amplitude, wavelength = swellAmplitude, swellWavelength
+		}
+		// Ctrl+drag flings the source with the cursor's velocity
+		var vx, vy float64
+		if ebiten.IsKeyPressed(ebiten.KeyControl) {
+			v := wavegrid.Vector2{X: float64(mx - g.cursorX), Y: float64(my - g.cursorY)}
+			if v.Length() > maxDriftSpeed {
+				v = v.Normalize().Scale(maxDriftSpeed)
+			}
+			vx, vy = v.X, v.Y
+		}
+		wavelength2 := 0.0
+		if g.dispersion {
+			wavelength2 = wavelength * dispersionWavelengthRatio
+		}
+		g.waveSources = append(g.waveSources, WaveSource{
+			x:            p.X,
+			y:            p.Y,
+			createdAt:    g.frame,
+			sourceRadius: radius,
+			amplitude:    amplitude,
+			wavelength:   wavelength,
+			wavelength2:  wavelength2,
+			hue:          g.nextHue,
+			vx:           vx,
+			vy:           vy,
+		})
+		g.nextHue = math.Mod(g.nextHue+sourceHueStep, 360)
+	}
+}
+
+// capSources drops the oldest pulses past cfg.MaxSources, since every source
+// is evaluated for every point each frame and the frame rate drops with the
+// count. The continuous sources of the interference demo are always kept.
+func (g *AnalyticGame) capSources() {
+	excess := len(g.waveSources) - g.cfg.MaxSources
+	g.keepSources(func(_ int, source WaveSource) bool {
+		if excess > 0 && !source.continuous {
+			excess--
+			return false
+		}
+		return true
+	})
+}
+
+// emitPacket launches a wave packet from the cursor if it is inside the boundary.
+func (g *AnalyticGame) emitPacket(mx, my int) {
+	p, ok := g.shape.SnapInside(float64(mx), float64(my), edgeTolerance)
+	if !ok {
+		return
+	}
+	x, y := p.X, p.Y
+	g.waveSources = append(g.waveSources, WaveSource{
+		x:          x,
+		y:          y,
+		createdAt:  g.frame,
+		amplitude:  defaultAmplitude,
+		wavelength: defaultWavelength,
+		hue:        g.nextHue,
+		packet:     true,
+	})
+	g.nextHue = math.Mod(g.nextHue+sourceHueStep, 360)
+}
+
+// placeInterferencePair replaces the continuous sources with a pair matching
+// the interference settings, or just removes them when the demo is off. The
+// pair keeps its start time so changing the phase shifts the fringes in place.
+func (g *AnalyticGame) placeInterferencePair() {
+	createdAt := g.frame
+	g.keepSources(func(_ int, source WaveSource) bool {
+		if source.continuous {
+			createdAt = source.createdAt
+		}
+		return !source.continuous
+	})
+	if !g.interference {
+		return
+	}
+
+	half := g.sourceSeparation / 2
+	for _, s := range []struct{ x, phase, hue float64 }{{g.cx - half, 0, 200}, {g.cx + half, g.relativePhase, 30}} {
+		g.waveSources = append(g.waveSources, WaveSource{
+			x:          s.x,
+			y:          g.cy,
+			createdAt:  createdAt,
+			amplitude:  continuousAmplitude,
+			wavelength: defaultWavelength,
+			phase:      s.phase,
+			hue:        s.hue,
+			continuous: true,
+		})
+	}
+}
+
+func (g *AnalyticGame) Draw(screen *ebiten.Image) {
+	screen.Fill(theme.Background)
+
+	// Draw boundary circle
+	vector.StrokeCircle(screen, float32(g.cx), float32(g.cy), float32(g.shapeRadius), 2, theme.Boundary, false)
+
+	// Draw grid of points, summing height² over them to estimate the energy
+	energy := 0.0
+	if g.histogram != nil {
+		g.histogram.reset(maxAnalyticHeight)
+	}
+	heightFn := func(x, y float64) float64 {
+		var height float64
+		if g.steadyState {
+			// A harmonic wave of amplitude a averages a²/2 in height², so
+			// the graph shows the level the transient energy settles to
+			height = math.Min(maxAnalyticHeight, g.steadyAmplitude(x, y))
+			energy += height * height / 2 * analyticGridSize * analyticGridSize
+		} else {
+			g.components = g.waveComponents(x, y, g.components[:0])
+			height = clampHeight(g.components, g.clampMode)
+			energy += height * height * analyticGridSize * analyticGridSize
+		}
+		if g.histogram != nil {
+			g.histogram.add(height)
+		}
+		return height
+	}
+	colorMap := g.colorMap
+	if g.tinted && !g.steadyState {
+		colorMap = sourceTint{g}
+	}
+	g.drawHeightField(screen, heightFn, colorMap)
+
+	// Draw runs every frame, but only a new tick adds a sample
+	if g.frame != g.lastEnergyFrame {
+		g.energy.push(energy)
+		g.lastEnergyFrame = g.frame
+	}
+
+	// Capture before the overlay text is drawn
+	if g.screenshotRequested {
+		g.screenshotRequested = false
+		name, err := savePNG("wave", screenImage(screen))
+		if err != nil {
+			log.Printf("saving frame: %v", err)
+		} else {
+			log.Printf("frame saved to %s", name)
+		}
+	}
+
+	g.drawMinimap(screen)
+	drawGraph(screen, "Energy", g.energy, 0)
+	g.heightProbe.draw(screen, g.heightProbe.x, g.heightProbe.y, 1)
+	if g.histogram != nil {
+		g.histogram.draw(screen, "Heights", 2)
+	}
+	if g.ruler != nil {
+		g.ruler.draw(screen, defaultWavelength)
+	}
+	if g.showFronts {
+		g.drawFronts(screen)
+	}
+	var newestPacket *WaveSource
+	for i, source := range g.waveSources {
+		if source.packet {
+			g.drawPacketEnvelope(screen, source)
+			newestPacket = &g.waveSources[i]
+		}
+	}
+	dispersion := "off"
+	if g.dispersion {
+		dispersion = "on"
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Click inside the circle to create waves | Right click for a swell | Shift+click for a wide source | Drag for a wake | Ctrl+drag to fling a moving source\nR to reset | X to calm the water but keep the sources | Space to pause, . to step | S to save PNG | I for two-source interference | K for colors (%s), Shift+K for the %s theme | Reflectivity: %.1f (PgUp/PgDn) | D for dispersion (%s) | U for a ruler | A for the steady state | H to tint by source | P for a wave packet | Drag a source to move it | Y for a height histogram | Clamp: %s (C)", g.colorMap, theme.name, g.reflectivity, dispersion, g.clampMode))
+	if g.paused {
+		ebitenutil.DebugPrintAt(screen, "PAUSED", 0, 32)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Bounces: %d (B) | T to trace the wavefronts", g.cfg.Bounces), 0, 112)
+	sources := fmt.Sprintf("Sources: %d/%d", len(g.waveSources), g.cfg.MaxSources)
+	if float64(len(g.waveSources)) >= sourceWarning*float64(g.cfg.MaxSources) {
+		debugPrintTinted(screen, sources+" | the oldest are dropped past the limit", 0, 96, warningColor)
+	} else {
+		ebitenutil.DebugPrintAt(screen, sources, 0, 96)
+	}
+	if p := newestPacket; p != nil {
+		if t := float64(g.frame - p.createdAt); t > 0 {
+			peak, _, _ := g.packetPeak(*p)
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Packet: envelope %.2f px/tick, crests %.2f px/tick (D for dispersion)", peak/t, g.waveSpeed(p.wavelength)), 0, 80)
+		}
+	}
+	if g.steadyState {
+		ebitenutil.DebugPrintAt(screen, "Steady-state amplitude of the continuous sources (I) | A for the live field", 0, 64)
+	}
+	if g.interference {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Interference: separation %.0f (-/=) | phase %.0f deg ([/])", g.sourceSeparation, g.relativePhase*180/math.Pi), 0, 48)
+	}
+}
+
+// steadyAmplitude returns the amplitude the continuous sources settle to at
+// (x, y) once their fronts have passed: each contributes a phasor of its
+// amplitude at the phase of its wave there, and the pattern is the magnitude
+// of their sum. Pulses die out, so they don't count.
+func (g *AnalyticGame) steadyAmplitude(x, y float64) float64 {
+	var sum complex128
+	for _, source := range g.waveSources {
+		if !source.continuous {
+			continue
+		}
+		dist := math.Hypot(x-source.x, y-source.y)
+		phase := (dist/source.wavelength)*2*math.Pi + source.phase
+		sum += cmplx.Rect(source.amplitude, phase)
+	}
+	return cmplx.Abs(sum)
+}
+
+// waveComponents appends to out the height each source adds at (x, y), its
+// outgoing wave plus its reflections, in the order of waveSources.
+func (g *AnalyticGame) waveComponents(x, y float64, out []float64) []float64 {
+	for _, source := range g.waveSources {
+		// A single bounce is the sampled echo, while more all come from the
+		// channel images, so every echo of a pulse weakens by the same rule
+		var echoes float64
+		if g.cfg.Bounces > 1 {
+			echoes = g.calculateChannelImages(x, y, source)
+		} else {
+			echoes = g.calculateReflectedWaves(x, y, source)
+		}
+		out = append(out, g.calculateOutgoingWave(x, y, source)+echoes)
+	}
+	return out
+}
+
+// drawHeightField draws a dot every analyticGridSize pixels inside the pond,
+// colored by colorMap at the height heightFn gives there, relative to
+// maxAnalyticHeight, and growing with it. Every analytic view draws through
+// it, so they all map heights to colors the same way.
+func (g *AnalyticGame) drawHeightField(screen *ebiten.Image, heightFn func(x, y float64) float64, colorMap ColorMap) {
+	g.heightFieldDots(heightFn, colorMap, func(x, y float64, radius float32, c color.RGBA) {
+		vector.DrawFilledCircle(screen, float32(x), float32(y), radius, c, false)
+	})
+}
+
+// heightFieldDots calls dot with the position, radius and color of every dot
+// drawHeightField draws, in the order it draws them.
+func (g *AnalyticGame) heightFieldDots(heightFn func(x, y float64) float64, colorMap ColorMap, dot func(x, y float64, radius float32, c color.RGBA)) {
+	for xi := int(g.cx - g.shapeRadius); xi < int(g.cx+g.shapeRadius); xi += analyticGridSize {
+		for yi := int(g.cy - g.shapeRadius); yi < int(g.cy+g.shapeRadius); yi += analyticGridSize {
+			px := float64(xi)
+			py := float64(yi)
+
+			dx := px - g.cx
+			dy := py - g.cy
+			if math.Sqrt(dx*dx+dy*dy) >= g.shapeRadius {
+				continue
+			}
+
+			height := heightFn(px, py)
+			c := colorMap.At(height / maxAnalyticHeight)
+			radius := float32(math.Max(1.0, 1.5+math.Abs(height)*2))
+			dot(px, py, radius, c)
+		}
+	}
+}
+
+// sourceTint is the ColorMap of tinted views. It colors by the sources behind
+// the height heightFn computed last, so it only works within drawHeightField.
+type sourceTint struct{ g *AnalyticGame }
+
+func (t sourceTint) At(norm float64) color.RGBA {
+	return t.g.tintedColor(t.g.components, norm*maxAnalyticHeight)
+}
+
+func (sourceTint) String() string { return "by source" }
+
+// tintedColor blends the hues of the sources by how strongly each moves a
+// point, given its waveComponents, lit from dark in troughs to full in crests
+// by the summed height.
+func (g *AnalyticGame) tintedColor(components []float64, height float64) color.RGBA {
+	var r, gr, b, weight float64
+	for i, h := range components {
+		w := math.Abs(h)
+		tint := hueColor(g.waveSources[i].hue)
+		r += w * float64(tint.R)
+		gr += w * float64(tint.G)
+		b += w * float64(tint.B)
+		weight += w
+	}
+	if weight == 0 {
+		return g.colorMap.At(0)
+	}
+	tint := color.RGBA{uint8(r / weight), uint8(gr / weight), uint8(b / weight), 255}
+	light := 0.25 + 0.75*(height/maxAnalyticHeight+1)/2
+	return lerpColor(color.RGBA{0, 0, 0, 255}, tint, light)
+}
+
+func (g *AnalyticGame) calculateWaveHeight(x, y float64) float64 {
+	g.components = g.waveComponents(x, y, g.components[:0])
+	return clampHeight(g.components, g.clampMode)
+}
+
+// ClampMode is how clampHeight keeps summed heights within ±maxAnalyticHeight.
+type ClampMode int
+
+const (
+	HardClamp ClampMode = iota // clip, which flattens bright crests
+	SoftClamp                  // compress with tanh, so bright crests keep their shape
+)
+
+func (m ClampMode) String() string {
+	if m == SoftClamp {
+		return "soft"
+	}
+	return "hard"
+}
+
+// apply brings h within ±maxAnalyticHeight. The soft clamp is smooth, odd and
+// strictly increasing, so taller sums still show taller, but it also lowers
+// small heights slightly, by 3.5% at a third of the range.
+func (m ClampMode) apply(h float64) float64 {
+	if m == SoftClamp {
+		return maxAnalyticHeight * math.Tanh(h/maxAnalyticHeight)
+	}
+	return math.Max(-maxAnalyticHeight, math.Min(maxAnalyticHeight, h))
+}
+
+// clampHeight sums the contributions of the sources at a point, clamped to
+// ±maxAnalyticHeight by mode.
+func clampHeight(components []float64, mode ClampMode) float64 {
+	totalHeight := 0.0
+
+	// Sum contributions from all wave sources, outgoing and reflected
+	// This creates superposition - waves add together
+	for _, h := range components {
+		totalHeight += h
+	}
+
+	// Clamp height - this creates interference patterns
+	// When waves add constructively, amplitude increases
+	// When waves add destructively, they cancel out
+	return mode.apply(totalHeight)
+}
+
+func (g *AnalyticGame) calculateOutgoingWave(x, y float64, source WaveSource) float64 {
+	if source.sourceRadius == 0 {
+		return g.calculatePointWave(x, y, source.x, source.y, source)
+	}
+
+	// Approximate the integral over the source disk with its center plus a ring
+	// of samples at the radius that splits the disk into two equal areas
+	total := g.calculatePointWave(x, y, source.x, source.y, source)
+	ringRadius := source.sourceRadius * math.Sqrt(0.5)
+	for i := 0; i < sourceDiskSamples; i++ {
+		angle := (float64(i) / float64(sourceDiskSamples)) * 2 * math.Pi
+		sx := source.x + ringRadius*math.Cos(angle)
+		sy := source.y + ringRadius*math.Sin(angle)
+		total += g.calculatePointWave(x, y, sx, sy, source)
+	}
+	return total / float64(sourceDiskSamples+1)
+}
+
+// waveSpeed returns how fast a wave of the given wavelength travels, in
+// pixels per tick. Without dispersion every wave moves at analyticWaveSpeed.
+// With it, speeds follow deep-water gravity waves, c = sqrt(g*wavelength/2pi),
+// so c grows with the square root of the wavelength; the scale is chosen so
+// defaultWavelength keeps analyticWaveSpeed.
+func (g *AnalyticGame) waveSpeed(wavelength float64) float64 {
+	if !g.dispersion {
+		return analyticWaveSpeed
+	}
+	return analyticWaveSpeed * math.Sqrt(wavelength/defaultWavelength)
+}
+
+// calculatePointWave returns the outgoing wave of source as if it were emitted
+// from (sx, sy). A dispersive pulse splits its amplitude between its two
+// components, which travel at their own speeds.
+func (g *AnalyticGame) calculatePointWave(x, y, sx, sy float64, source WaveSource) float64 {
+	if source.packet {
+		h, _ := g.packetWave(math.Hypot(x-sx, y-sy), float64(g.frame-source.createdAt), source)
+		return h
+	}
+	if source.wavelength2 > 0 {
+		return (g.calculateComponentWave(x, y, sx, sy, source.wavelength, source) +
+			g.calculateComponentWave(x, y, sx, sy, source.wavelength2, source)) / 2
+	}
+	return g.calculateComponentWave(x, y, sx, sy, source.wavelength, source)
+}
+
+// calculateComponentWave returns the part of the wave of source with the given wavelength.
+func (g *AnalyticGame) calculateComponentWave(x, y, sx, sy, wavelength float64, source WaveSource) float64 {
+	dx := x - sx
+	dy := y - sy
+	distFromSource := math.Sqrt(dx*dx + dy*dy)
+
+	amplitude := source.amplitude
+	timeElapsed := float64(g.frame - source.createdAt)
+	waveFront := g.waveFront(source, wavelength)
+
+	if distFromSource < waveFront {
+		distanceFromFront := distFromSource - waveFront
+		waveInfluence := 30.0
+
+		if source.continuous {
+			// A steady train traveling outward fills everything the front has passed
+			phase := (distanceFromFront/wavelength)*2*math.Pi + source.phase
+			return amplitude * math.Sin(phase)
+		}
+
+		if math.Abs(distanceFromFront) < waveInfluence {
+			envelope := math.Exp(-(distanceFromFront * distanceFromFront) / (waveInfluence * waveInfluence))
+			phase := (distFromSource/wavelength)*2*math.Pi + source.phase
+			wave := amplitude * math.Sin(phase) * envelope
+			damping := math.Exp(-timeElapsed / 300)
+			return wave * damping
+		}
+	}
+	return 0.0
+}
+
+// waveFront returns how far the front of the part of source with the given
+// wavelength has traveled from it.
+func (g *AnalyticGame) waveFront(source WaveSource, wavelength float64) float64 {
+	return g.waveSpeed(wavelength) * float64(g.frame-source.createdAt)
+}
+
+// drawFronts strokes the circle the outgoing front of each source has reached
+// in its hue, both fronts of a dispersive pulse, to compare with where the
+// rendered crests are. Packets are left out since their envelope is drawn.
+// A front stops being drawn once it is past every point of the pond.
+func (g *AnalyticGame) drawFronts(screen *ebiten.Image) {
+	for _, source := range g.waveSources {
+		if source.packet {
+			continue
+		}
+		farthest := math.Hypot(source.x-g.cx, source.y-g.cy) + g.shapeRadius
+		for _, wavelength := range []float64{source.wavelength, source.wavelength2} {
+			if wavelength == 0 {
+				continue
+			}
+			if r := g.waveFront(source, wavelength); r > 0 && r < farthest {
+				vector.StrokeCircle(screen, float32(source.x), float32(source.y), float32(r), 1, hueColor(source.hue), true)
+			}
+		}
+	}
+}
+
+// calculateReflectedWaves returns the echo of source off the circular
+// boundary, for a pulse that bounces only once. Only the main wavelength of a dispersive pulse is reflected, to
+// keep the cost of the boundary samples down.
+func (g *AnalyticGame) calculateReflectedWaves(x, y float64, source WaveSource) float64 {
+	wavelength := source.wavelength
+	waveSpeed := g.waveSpeed(wavelength)
+	amplitude := source.amplitude
+	timeElapsed := float64(g.frame - source.createdAt)
+
+	// Only calculate reflections if enough time has passed. Continuous sources
+	// aren't reflected, so their fringes stay clean, and neither are packets,
+	// whose envelope is easier to follow alone
+	if timeElapsed < 50 || source.continuous || source.packet {
+		return 0.0
+	}
+
+	totalReflectedHeight := 0.0
+
+	// Instead of sampling all boundary points, calculate the reflection analytically
+	// Find the angle from center to the current point
+	pointDx := x - g.cx
+	pointDy := y - g.cy
+	pointAngle := math.Atan2(pointDy, pointDx)
+
+	// For the reflection, we need to find where on the boundary the wave will reflect
+	// The wave travels from source outward, so it hits different boundary points at different times
+	// We'll sample a few angles around the current point's angle for efficiency
+
+	sampleAngles := 8
+	for i := 0; i < sampleAngles; i++ {
+		// Sample around the point's angle
+		offsetAngle := float64(i-sampleAngles/2) * (math.Pi / float64(sampleAngles))
+		angle := pointAngle + offsetAngle
+
+		// Point on the boundary
+		boundaryX := g.cx + g.shapeRadius*math.Cos(angle)
+		boundaryY := g.cy + g.shapeRadius*math.Sin(angle)
+
+		// Distance from source to this boundary point
+		distToBoundary := math.Sqrt((boundaryX-source.x)*(boundaryX-source.x) + (boundaryY-source.y)*(boundaryY-source.y))
+
+		// Time when the wave from source reaches this boundary point
+		timeToReachBoundary := distToBoundary / waveSpeed
+
+		// Has the wave reached this boundary point yet?
+		if timeElapsed > timeToReachBoundary {
+			timeSinceReflection := timeElapsed - timeToReachBoundary
+
+			// Distance the reflected wave has traveled from this boundary point
+			reflectedWaveFront := waveSpeed * timeSinceReflection
+
+			// Distance from current point to this boundary reflection point
+			reflDx := x - boundaryX
+			reflDy := y - boundaryY
+			distFromReflectionPoint := math.Sqrt(reflDx*reflDx + reflDy*reflDy)
+
+			// Check if reflected wave reaches this point
+			if distFromReflectionPoint < reflectedWaveFront {
+				distanceFromReflectedFront := distFromReflectionPoint - reflectedWaveFront
+				waveInfluence := 30.0
+
+				if math.Abs(distanceFromReflectedFront) < waveInfluence {
+					envelope := math.Exp(-(distanceFromReflectedFront * distanceFromReflectedFront) / (waveInfluence * waveInfluence))
+					phase := (distFromReflectionPoint / wavelength) * 2 * math.Pi
+					wave := amplitude * math.Sin(phase) * envelope * g.reflectivity
+					damping := math.Exp(-timeElapsed / 350)
+					totalReflectedHeight += wave * damping
+				}
+			}
+		}
+	}
+
+	return totalReflectedHeight / float64(sampleAngles)
+}
+
+// calculateChannelImages returns the echoes of source off the boundary, up to
+// cfg.Bounces of them, by the method of images: the echo after n bounces is
+// the outgoing wave of an image of the source outside the boundary, weakened
+// by the reflectivity n times.
+//
+// The images are not those of the circle, which has no exact images for
+// waves: inverting the source through it, a → R²/a, only gives the image of a
+// static field. Instead the circle is approximated by a channel, its two
+// tangent lines at the ends of the diameter through (x, y), whose images are
+// those of a mirror pair. Reflecting the source across one line and then the
+// other, n times in all, puts the n-bounce images 2nR from the center along
+// the diameter, on alternating sides of it,
+//
+//	along = 2kR + (-1)^k·a,  k = ±n,
+//
+// where a is the source's position along the diameter, and its distance off
+// the diameter stays as it is. That is exact for a source at the center,
+// whose rings refocus there after every bounce, and for points on the
+// diameter through the source, and close nearby, which is where the echoes
+// of a small cavity pile up into standing patterns. Elsewhere the curve of
+// the boundary focuses the echoes in ways the channel misses.
+func (g *AnalyticGame) calculateChannelImages(x, y float64, source WaveSource) float64 {
+	if source.continuous || source.packet {
+		return 0.0
+	}
+
+	// The diameter through the point, and the source along it and off it
+	ux, uy := 1.0, 0.0
+	if r := math.Hypot(x-g.cx, y-g.cy); r > 0 {
+		ux, uy = (x-g.cx)/r, (y-g.cy)/r
+	}
+	along := (source.x-g.cx)*ux + (source.y-g.cy)*uy
+	across := (source.y-g.cy)*ux - (source.x-g.cx)*uy
+
+	total := 0.0
+	strength := 1.0
+	for n := 1; n <= g.cfg.Bounces; n++ {
+		strength *= g.reflectivity
+		sign := 1.0
+		if n%2 == 1 {
+			sign = -1
+		}
+		for _, k := range []float64{float64(n), -float64(n)} {
+			imageAlong := 2*k*g.shapeRadius + sign*along
+			sx := g.cx + imageAlong*ux - across*uy
+			sy := g.cy + imageAlong*uy + across*ux
+			total += strength * g.calculateComponentWave(x, y, sx, sy, source.wavelength, source)
+		}
+	}
+	return total
+}
+
+func (g *AnalyticGame) Layout(w, h int) (int, int) {
+	return g.cfg.ScreenWidth, g.cfg.ScreenHeight
+}
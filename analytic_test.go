@@ -0,0 +1,116 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// TestPruneSources lets a pulse decay past sourceDecayCutoff next to a
+// continuous source. The pulse must go on the first frame its amplitude drops
+// below the cutoff, not before, while the continuous source stays.
+func TestPruneSources(t *testing.T) {
+	g := NewAnalyticGame(DefaultConfig())
+	g.waveSources = []WaveSource{{amplitude: 10}, {amplitude: 10, continuous: true}}
+
+	// 10·e^(-t/300) reaches the cutoff at t = 300·ln(10/cutoff), about 2072
+	last := int(300 * math.Log(10/sourceDecayCutoff))
+	g.frame = last
+	g.pruneSources()
+	if len(g.waveSources) != 2 {
+		t.Fatalf("pruned at frame %d, before the pulse decayed below the cutoff", last)
+	}
+	g.frame = last + 1
+	g.pruneSources()
+	if len(g.waveSources) != 1 || !g.waveSources[0].continuous {
+		t.Fatalf("after frame %d, sources = %+v, want only the continuous one", last+1, g.waveSources)
+	}
+}
+
+// heightDot is a dot of the analytic height field as drawn.
+type heightDot struct {
+	x, y   float64
+	radius float32
+	c      color.RGBA
+}
+
+// v6Dots returns the dots _mainv6.go drew on its 1000×800 screen for the
+// heights heightFn gives, with its own grid loop and color math.
+func v6Dots(heightFn func(x, y float64) float64) []heightDot {
+	const centerX, centerY, shapeRadius, gridSize = 500.0, 400.0, 200.0, 4
+	var dots []heightDot
+	for xi := int(centerX - shapeRadius); xi < int(centerX+shapeRadius); xi += gridSize {
+		for yi := int(centerY - shapeRadius); yi < int(centerY+shapeRadius); yi += gridSize {
+			px, py := float64(xi), float64(yi)
+			if math.Hypot(px-centerX, py-centerY) >= shapeRadius {
+				continue
+			}
+			height := heightFn(px, py)
+			var r, g, b uint8
+			if height > 0 {
+				intensity := uint8(math.Min(height*200, 255))
+				r, g, b = 100, uint8(150+int(intensity)/2), 220
+			} else {
+				intensity := uint8(math.Min(-height*200, 255))
+				r, g, b = 220, uint8(150-int(intensity)/2), 100
+			}
+			dots = append(dots, heightDot{px, py, float32(math.Max(1.0, 1.5+math.Abs(height)*2)), color.RGBA{r, g, b, 255}})
+		}
+	}
+	return dots
+}
+
+// TestHeightFieldMatchesV6 draws the same heights through drawHeightField
+// with the classic map and through the loop of _mainv6.go, the reference the
+// shared function was extracted from. Every dot must land in the same place
+// with the same size and color. The variant blended its dots at 200/255
+// opacity where ClassicMap is opaque, so only the alpha is set aside.
+func TestHeightFieldMatchesV6(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ScreenWidth, cfg.ScreenHeight, cfg.ShapeRadius = 1000, 800, 200
+	g := NewAnalyticGame(cfg)
+	heightFn := func(x, y float64) float64 {
+		return maxAnalyticHeight * math.Sin(x/17) * math.Cos(y/23)
+	}
+
+	var got []heightDot
+	g.heightFieldDots(heightFn, ClassicMap{}, func(x, y float64, radius float32, c color.RGBA) {
+		got = append(got, heightDot{x, y, radius, c})
+	})
+	want := v6Dots(heightFn)
+	if len(got) != len(want) {
+		t.Fatalf("drew %d dots, _mainv6.go %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("dot %d = %+v, _mainv6.go drew %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestSoftClamp sweeps the soft clamp over heights far past the range. It
+// must stay within ±maxAnalyticHeight, never fall as the height rises, and
+// rise strictly wherever float64 can still tell tanh from 1, so sums the hard
+// clamp flattens to the same crest keep their order.
+func TestSoftClamp(t *testing.T) {
+	const step = 0.01
+	prev := SoftClamp.apply(-100)
+	for h := -100 + step; h <= 100; h += step {
+		got := SoftClamp.apply(h)
+		if math.Abs(got) > maxAnalyticHeight {
+			t.Fatalf("soft clamp of %g = %g, beyond ±%g", h, got, maxAnalyticHeight)
+		}
+		if got < prev || math.Abs(h) < 10 && got == prev {
+			t.Fatalf("soft clamp of %g = %g, not above %g just below it", h, got, prev)
+		}
+		prev = got
+	}
+	for _, h := range []float64{math.Inf(1), math.Inf(-1)} {
+		if got := SoftClamp.apply(h); math.Abs(got) != maxAnalyticHeight {
+			t.Errorf("soft clamp of %g = %g, want ±%g", h, got, maxAnalyticHeight)
+		}
+	}
+	if HardClamp.apply(2) != HardClamp.apply(3) || SoftClamp.apply(2) >= SoftClamp.apply(3) {
+		t.Error("heights of 2 and 3 should clip to the same crest but compress to different ones")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const (
+	probeSampleRate = 44100
+	probeToneHz     = 220.0                 // carrier whose loudness follows the probed height
+	probeSmoothing  = 0.002                 // per-sample easing towards a new level, avoids clicks
+	probeBufferTime = 50 * time.Millisecond // keeps latency low so the sound tracks the field
+)
+
+// AudioProbe plays a tone whose signed amplitude follows the wave height at a
+// point, so the slow standing-wave oscillations of the pond become audible as
+// a pulsing note.
+type AudioProbe struct {
+	x, y   float64 // probe position in pixels, like addWave
+	player *audio.Player
+
+	mu     sync.Mutex
+	target float64 // level set from the simulation, in [-1, 1]
+	level  float64 // level being played, eased towards target
+	phase  float64 // carrier phase in radians
+}
+
+func NewAudioProbe(x, y float64) (*AudioProbe, error) {
+	p := &AudioProbe{x: x, y: y}
+	player, err := audio.NewContext(probeSampleRate).NewPlayerF32(p)
+	if err != nil {
+		return nil, err
+	}
+	player.SetBufferSize(probeBufferTime)
+	player.Play()
+	p.player = player
+	return p, nil
+}
+
+// sample sets the played level from the height at the probe, with norm the
+// height that plays at full volume.
+func (p *AudioProbe) sample(wg *WaveGrid, norm float64) {
+	h := wg.HeightAt(int(p.x/wg.CellSize()), int(p.y/wg.CellSize()))
+	p.mu.Lock()
+	p.target = math.Max(-1, math.Min(1, h/norm))
+	p.mu.Unlock()
+}
+
+// Read fills buf with stereo 32-bit float frames. It is called from the audio
+// goroutine and never ends the stream.
+func (p *AudioProbe) Read(buf []byte) (int, error) {
+	p.mu.Lock()
+	target := p.target
+	p.mu.Unlock()
+
+	const frameSize = 8 // two channels of 4 bytes
+	n := len(buf) / frameSize * frameSize
+	for i := 0; i < n; i += frameSize {
+		p.level += (target - p.level) * probeSmoothing
+		p.phase += 2 * math.Pi * probeToneHz / probeSampleRate
+		if p.phase > 2*math.Pi {
+			p.phase -= 2 * math.Pi
+		}
+		v := math.Float32bits(float32(p.level * math.Sin(p.phase)))
+		binary.LittleEndian.PutUint32(buf[i:], v)
+		binary.LittleEndian.PutUint32(buf[i+4:], v)
+	}
+	return n, nil
+}
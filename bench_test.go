@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Benchmarks of the hot paths; BenchmarkGridStep lives with the solver in
+// wavegrid. Run and profile them a package at a time with
+//
+//	go test -bench . -cpuprofile cpu.out
+//	go test -bench . -cpuprofile cpu.out ./wavegrid
+//
+// then read the profile with go tool pprof cpu.out.
+
+const benchAnalyticSources = 20 // a busy pond, about what a few drags leave behind
+
+// BenchmarkAnalyticHeight times calculateWaveHeight over the points the
+// analytic view draws in one frame, with benchAnalyticSources pulses spread
+// over the pond and old enough to be reflecting.
+func BenchmarkAnalyticHeight(b *testing.B) {
+	g := NewAnalyticGame(DefaultConfig())
+	for i := range benchAnalyticSources {
+		angle := 2 * math.Pi * float64(i) / benchAnalyticSources
+		g.waveSources = append(g.waveSources, WaveSource{
+			x:          g.cx + 0.5*g.shapeRadius*math.Cos(angle),
+			y:          g.cy + 0.5*g.shapeRadius*math.Sin(angle),
+			amplitude:  defaultAmplitude,
+			wavelength: defaultWavelength,
+		})
+	}
+	g.frame = 100
+
+	b.ResetTimer()
+	for range b.N {
+		for x := g.cx - g.shapeRadius; x < g.cx+g.shapeRadius; x += analyticGridSize {
+			for y := g.cy - g.shapeRadius; y < g.cy+g.shapeRadius; y += analyticGridSize {
+				g.calculateWaveHeight(x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// ColorMap turns a height normalized to [-1, 1] into a display color.
+type ColorMap interface {
+	At(normalized float64) color.RGBA
+	String() string
+}
+
+// colorMaps are the maps K cycles through, in order.
+var colorMaps = []ColorMap{DivergingMap{}, GrayscaleMap{}, ViridisMap{}, ClassicMap{}, ThemeMap{}}
+
+// hueColor returns the fully saturated, full brightness color of hue degrees.
+func hueColor(hue float64) color.RGBA {
+	h := math.Mod(hue, 360) / 60
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g, b = 1, x, 0
+	case 1:
+		r, g, b = x, 1, 0
+	case 2:
+		r, g, b = 0, 1, x
+	case 3:
+		r, g, b = 0, x, 1
+	case 4:
+		r, g, b = x, 0, 1
+	default:
+		r, g, b = 1, 0, x
+	}
+	return color.RGBA{uint8(255 * r), uint8(255 * g), uint8(255 * b), 255}
+}
+
+// nextColorMap returns the map after m in colorMaps, wrapping around.
+func nextColorMap(m ColorMap) ColorMap {
+	for i, c := range colorMaps {
+		if c == m {
+			return colorMaps[(i+1)%len(colorMaps)]
+		}
+	}
+	return colorMaps[0]
+}
+
+// DivergingMap shows crests in bright blue and troughs in dark red.
+type DivergingMap struct{}
+
+func (DivergingMap) At(norm float64) color.RGBA {
+	norm = math.Max(-1, math.Min(1, norm))
+
+	var r, g, b uint8
+	if norm > 0 {
+		// Crest: bright blue
+		b = uint8(150 + norm*100)
+		g = uint8(120 + norm*60)
+		r = uint8(40 + norm*40)
+	} else {
+		// Trough: darker, reddish
+		r = uint8(100 - norm*80)
+		g = uint8(100 - norm*60)
+		b = uint8(120 - norm*40)
+	}
+	return color.RGBA{r, g, b, 255}
+}
+
+func (DivergingMap) String() string { return "diverging" }
+
+// GrayscaleMap runs from black in the deepest trough to white on the highest crest.
+type GrayscaleMap struct{}
+
+func (GrayscaleMap) At(norm float64) color.RGBA {
+	v := uint8(255 * (math.Max(-1, math.Min(1, norm)) + 1) / 2)
+	return color.RGBA{v, v, v, 255}
+}
+
+func (GrayscaleMap) String() string { return "grayscale" }
+
+// ViridisMap is the perceptually uniform viridis map, from dark purple in the
+// troughs to yellow on the crests.
+type ViridisMap struct{}
+
+// viridisStops samples viridis at evenly spaced points from 0 to 1.
+var viridisStops = [...]color.RGBA{
+	{68, 1, 84, 255},
+	{71, 45, 123, 255},
+	{59, 82, 139, 255},
+	{44, 114, 142, 255},
+	{33, 145, 140, 255},
+	{40, 174, 128, 255},
+	{94, 201, 98, 255},
+	{173, 220, 48, 255},
+	{253, 231, 37, 255},
+}
+
+func (ViridisMap) At(norm float64) color.RGBA {
+	t := (math.Max(-1, math.Min(1, norm)) + 1) / 2 * float64(len(viridisStops)-1)
+	i := min(int(t), len(viridisStops)-2)
+	return lerpColor(viridisStops[i], viridisStops[i+1], t-float64(i))
+}
+
+func (ViridisMap) String() string { return "viridis" }
+
+// ClassicMap is the map of the first analytic versions: light blue crests and
+// orange troughs, still water included, saturating at 85% of the range. They
+// drew it at 200/255 opacity.
+type ClassicMap struct{}
+
+// classicHeight is the height those versions clamped to, which their color
+// math is written in terms of.
+const classicHeight = 1.5
+
+func (ClassicMap) At(norm float64) color.RGBA {
+	h := norm * classicHeight
+	if h > 0 {
+		intensity := uint8(math.Min(h*200, 255))
+		return color.RGBA{100, uint8(150 + int(intensity)/2), 220, 255}
+	}
+	intensity := uint8(math.Min(-h*200, 255))
+	return color.RGBA{220, uint8(150 - int(intensity)/2), 100, 255}
+}
+
+func (ClassicMap) String() string { return "classic" }
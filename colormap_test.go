@@ -0,0 +1,42 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestColorMaps pins a few samples of each map, worked out by hand from its
+// definition: the ends of the range, still water, a point between stops and
+// heights past the range, which must clamp.
+func TestColorMaps(t *testing.T) {
+	for _, c := range []struct {
+		m    ColorMap
+		norm float64
+		want color.RGBA
+	}{
+		{DivergingMap{}, 1, color.RGBA{80, 180, 250, 255}},
+		{DivergingMap{}, 0, color.RGBA{100, 100, 120, 255}},
+		{DivergingMap{}, -1, color.RGBA{180, 160, 160, 255}},
+		{DivergingMap{}, -3, color.RGBA{180, 160, 160, 255}},
+
+		{GrayscaleMap{}, 1, color.RGBA{255, 255, 255, 255}},
+		{GrayscaleMap{}, 0, color.RGBA{127, 127, 127, 255}},
+		{GrayscaleMap{}, -1, color.RGBA{0, 0, 0, 255}},
+		{GrayscaleMap{}, 2, color.RGBA{255, 255, 255, 255}},
+
+		{ViridisMap{}, 1, color.RGBA{253, 231, 37, 255}},
+		{ViridisMap{}, 0, color.RGBA{33, 145, 140, 255}},
+		{ViridisMap{}, 0.125, color.RGBA{36, 159, 134, 255}}, // halfway between the middle stops
+		{ViridisMap{}, -1, color.RGBA{68, 1, 84, 255}},
+		{ViridisMap{}, -2, color.RGBA{68, 1, 84, 255}},
+
+		// A third of the range is a height of 0.5 in the old units
+		{ClassicMap{}, 1.0 / 3, color.RGBA{100, 200, 220, 255}},
+		{ClassicMap{}, 0, color.RGBA{220, 150, 100, 255}},
+		{ClassicMap{}, -1.0 / 3, color.RGBA{220, 100, 100, 255}},
+	} {
+		if got := c.m.At(c.norm); got != c.want {
+			t.Errorf("%v at %g = %v, want %v", c.m, c.norm, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "game/wavegrid"
+
+// Config holds the simulation settings, so the same binary can launch
+// different setups (a bigger pond, faster waves) without editing constants.
+type Config struct {
+	ScreenWidth  int
+	ScreenHeight int
+	GridSize     int     // pixels per grid cell
+	WaveSpeed    float64 // grid cells per step
+	Damping      float64
+	UpdateSteps  int // solver steps per 1/60 s
+	ZoomScale    float64
+	Shape        string  // pond layout, see configShapes
+	ShapeFile    string  // outline loaded with LoadShape, overrides Shape
+	ShapeRadius  float64 // radius of the circular pond, in pixels
+	ShoreWidth   int     // cells from the edge over which extra damping fades in, 0 for none
+	ShoreDamping float64 // damping right at the edge, relative to open water
+	Reflectivity float64 // share of a wave the pond edge reflects, 0 to 1
+	Periodic     bool    // wrap the grid edges around like a torus, for the "open" pond
+	Anisotropy   float64 // horizontal over vertical wave speed, 1 for the same both ways
+	SeedImage    string  // PNG F8 seeds the heights from, see SeedImage
+	RainMode     bool    // drop random impulses, see Rain
+	RainSeed     uint64
+	Collisions   bool // particles bounce off each other in particle mode
+	MaxSources   int  // sources in analytic mode, past which the oldest pulses are dropped
+	Bounces      int  // echoes off the boundary each pulse makes in analytic mode, see calculateChannelImages
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ScreenWidth:  1000,
+		ScreenHeight: 600,
+		GridSize:     1,
+		WaveSpeed:    0.5,
+		Damping:      1,
+		UpdateSteps:  5,
+		ZoomScale:    2.0,
+		Shape:        "circle",
+		ShapeRadius:  150,
+		ShoreWidth:   12,
+		ShoreDamping: 0.97,
+		Reflectivity: 1,
+		Anisotropy:   1,
+		SeedImage:    "seed.png",
+		MaxSources:   100,
+		Bounces:      1,
+	}
+}
+
+func (c Config) gridWidth() int {
+	return c.ScreenWidth / c.GridSize
+}
+
+func (c Config) gridHeight() int {
+	return c.ScreenHeight / c.GridSize
+}
+
+// stepTime is the real time in seconds covered by one grid solver step.
+func (c Config) stepTime() float64 {
+	return 1.0 / float64(60*c.UpdateSteps)
+}
+
+// gridParams returns the solver settings of cfg.
+func (c Config) gridParams() wavegrid.Params {
+	return wavegrid.Params{
+		Width:        c.ScreenWidth,
+		Height:       c.ScreenHeight,
+		GridSize:     c.GridSize,
+		WaveSpeed:    c.WaveSpeed,
+		Damping:      c.Damping,
+		ShoreWidth:   c.ShoreWidth,
+		ShoreDamping: c.ShoreDamping,
+		Reflectivity: c.Reflectivity,
+		Periodic:     c.Periodic,
+		Anisotropy:   c.Anisotropy,
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+// segment is a line piece of a contour, in grid cell coordinates.
+type segment struct {
+	x1, y1, x2, y2 float64
+}
+
+// contourSegments traces where the height field crosses level using marching
+// squares. Only squares whose four corners are inside the mask are traced.
+func (wg *WaveGrid) contourSegments(level float64) []segment {
+	width, height := wg.Size()
+	var segments []segment
+	for y := 0; y < height-1; y++ {
+		for x := 0; x < width-1; x++ {
+			if !wg.InMask(x, y) || !wg.InMask(x+1, y) || !wg.InMask(x, y+1) || !wg.InMask(x+1, y+1) {
+				continue
+			}
+
+			// Corners clockwise from top-left
+			c0 := wg.HeightAt(x, y)
+			c1 := wg.HeightAt(x+1, y)
+			c2 := wg.HeightAt(x+1, y+1)
+			c3 := wg.HeightAt(x, y+1)
+			a0, a1, a2, a3 := c0 > level, c1 > level, c2 > level, c3 > level
+			if a0 == a1 && a1 == a2 && a2 == a3 {
+				continue
+			}
+
+			fx, fy := float64(x), float64(y)
+			// Where the level crosses each edge, by linear interpolation
+			top := [2]float64{fx + crossing(c0, c1, level), fy}
+			right := [2]float64{fx + 1, fy + crossing(c1, c2, level)}
+			bottom := [2]float64{fx + crossing(c3, c2, level), fy + 1}
+			left := [2]float64{fx, fy + crossing(c0, c3, level)}
+
+			var points [][2]float64
+			if a0 != a1 {
+				points = append(points, top)
+			}
+			if a1 != a2 {
+				points = append(points, right)
+			}
+			if a3 != a2 {
+				points = append(points, bottom)
+			}
+			if a0 != a3 {
+				points = append(points, left)
+			}
+
+			if len(points) == 2 {
+				segments = append(segments, segment{points[0][0], points[0][1], points[1][0], points[1][1]})
+				continue
+			}
+
+			// Saddle: the average of the corners decides whether the two
+			// regions meet through the middle of the square
+			center := (c0+c1+c2+c3)/4 > level
+			if center == a0 {
+				segments = append(segments,
+					segment{top[0], top[1], right[0], right[1]},
+					segment{bottom[0], bottom[1], left[0], left[1]})
+			} else {
+				segments = append(segments,
+					segment{top[0], top[1], left[0], left[1]},
+					segment{right[0], right[1], bottom[0], bottom[1]})
+			}
+		}
+	}
+	return segments
+}
+
+// crossing returns how far from a towards b the level is reached, in [0, 1].
+func crossing(a, b, level float64) float64 {
+	if a == b {
+		return 0.5
+	}
+	return (level - a) / (b - a)
+}
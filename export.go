@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// maskImage renders the mask at grid resolution: white inside, black outside.
+func (wg *WaveGrid) maskImage() *image.Gray {
+	width, height := wg.Size()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if wg.InMask(x, y) {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+// saveMaskPNG writes the mask to mask_<frame>.png and returns the file name.
+func (wg *WaveGrid) saveMaskPNG(frame int) (string, error) {
+	name := fmt.Sprintf("mask_%06d.png", frame)
+	return name, writePNG(name, wg.maskImage())
+}
+
+// renderToImage renders the height field at grid resolution with the same
+// colors draw uses on screen.
+func (wg *WaveGrid) renderToImage(normValue float64) *image.RGBA {
+	width, height := wg.Size()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	wg.renderPixels(img.Pix, normValue)
+	return img
+}
+
+// screenImage copies what has been drawn on screen so far into an image.
+func screenImage(screen *ebiten.Image) *image.RGBA {
+	img := image.NewRGBA(screen.Bounds())
+	screen.ReadPixels(img.Pix)
+	return img
+}
+
+// savePNG writes img to a timestamped <prefix>_<time>.png and returns the file name.
+func savePNG(prefix string, img image.Image) (string, error) {
+	name := fmt.Sprintf("%s_%s.png", prefix, time.Now().Format("20060102_150405.000"))
+	return name, writePNG(name, img)
+}
+
+func writePNG(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fieldJSON is the layout ExportJSON writes: heights[y][x] is the height of
+// cell (x, y), whose top-left corner is at (x, y)·gridSize pixels.
+type fieldJSON struct {
+	GridWidth  int         `json:"gridWidth"`
+	GridHeight int         `json:"gridHeight"`
+	GridSize   float64     `json:"gridSize"`
+	Heights    [][]float64 `json:"heights"`
+}
+
+// ExportJSON writes the height field to w as JSON, for analysis outside the
+// simulator. JSON has no NaN or infinities, so NaN is written as 0 and
+// infinities as the largest finite float of their sign.
+func (wg *WaveGrid) ExportJSON(w io.Writer) error {
+	width, height := wg.Size()
+	heights := make([][]float64, height)
+	for y := range heights {
+		heights[y] = make([]float64, width)
+		for x := range heights[y] {
+			h := wg.Heights()[wg.Index(x, y)]
+			if math.IsNaN(h) {
+				h = 0
+			}
+			heights[y][x] = math.Max(-math.MaxFloat64, math.Min(math.MaxFloat64, h))
+		}
+	}
+	return json.NewEncoder(w).Encode(fieldJSON{
+		GridWidth:  width,
+		GridHeight: height,
+		GridSize:   wg.CellSize(),
+		Heights:    heights,
+	})
+}
+
+// saveFieldJSON exports the height field to a timestamped field_<time>.json
+// and returns the file name.
+func (wg *WaveGrid) saveFieldJSON() (string, error) {
+	name := fmt.Sprintf("field_%s.json", time.Now().Format("20060102_150405.000"))
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if err := wg.ExportJSON(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return name, f.Close()
+}
+
+// ExportProfileCSV writes the heights along the grid row through the center to
+// w as CSV, one x,height row per cell after a header, for plotting a cross
+// section of the waves. Cells outside the pond read as still water.
+func (wg *WaveGrid) ExportProfileCSV(w io.Writer) error {
+	width, height := wg.Size()
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"x", "height"}); err != nil {
+		return err
+	}
+	for x := 0; x < width; x++ {
+		h := wg.HeightAt(x, height/2)
+		if err := cw.Write([]string{strconv.Itoa(x), strconv.FormatFloat(h, 'g', -1, 64)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// saveProfileCSV exports the center row profile to a timestamped
+// profile_<time>.csv and returns the file name.
+func (wg *WaveGrid) saveProfileCSV() (string, error) {
+	name := fmt.Sprintf("profile_%s.csv", time.Now().Format("20060102_150405.000"))
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if err := wg.ExportProfileCSV(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return name, f.Close()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+
+	"game/wavegrid"
+)
+
+// TestRenderToImage sets one cell in the middle of the pond to a known height
+// and checks its pixel against the diverging color map worked out by hand,
+// and a pixel outside the pond against the background.
+func TestRenderToImage(t *testing.T) {
+	g := newTestGame(t)
+	wg := g.waveGrid
+	wg.Heights()[wg.Index(500, 300)] = 5
+
+	img := wg.renderToImage(10)
+	// Half of the normalizing height: r = 40+0.5·40, g = 120+0.5·60, b = 150+0.5·100
+	if got, want := img.RGBAAt(500, 300), (color.RGBA{60, 150, 200, 255}); got != want {
+		t.Errorf("pixel of a cell at height 5 = %v, want %v", got, want)
+	}
+	if got := img.RGBAAt(0, 0); got != theme.Background {
+		t.Errorf("pixel outside the pond = %v, want the background %v", got, theme.Background)
+	}
+}
+
+// newSmallGrid returns a width×height pond of 1-pixel cells, covering the
+// whole grid.
+func newSmallGrid(width, height int) *WaveGrid {
+	cfg := DefaultConfig()
+	cfg.ScreenWidth, cfg.ScreenHeight, cfg.GridSize = width, height, 1
+	return NewWaveGrid(cfg, wavegrid.Rect(float64(width)/2, float64(height)/2, float64(width), float64(height)))
+}
+
+// TestExportJSON exports a small grid holding a distinct height in every
+// cell, NaN and infinities among them, and reads it back. Every finite height
+// must come back exactly where it was, NaN as 0 and the infinities clamped.
+func TestExportJSON(t *testing.T) {
+	const width, height = 7, 5
+	wg := newSmallGrid(width, height)
+	heights := wg.Heights()
+	for i := range heights {
+		heights[i] = float64(i)/3 - 4
+	}
+	heights[wg.Index(1, 1)] = math.NaN()
+	heights[wg.Index(2, 3)] = math.Inf(1)
+	heights[wg.Index(6, 4)] = math.Inf(-1)
+
+	var buf bytes.Buffer
+	if err := wg.ExportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got fieldJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GridWidth != width || got.GridHeight != height || got.GridSize != 1 || len(got.Heights) != height {
+		t.Fatalf("exported a %dx%d grid of %g-pixel cells with %d rows, want %dx%d of 1 with %d",
+			got.GridWidth, got.GridHeight, got.GridSize, len(got.Heights), width, height, height)
+	}
+	for y, row := range got.Heights {
+		if len(row) != width {
+			t.Fatalf("row %d has %d heights, want %d", y, len(row), width)
+		}
+		for x, h := range row {
+			want := heights[wg.Index(x, y)]
+			switch {
+			case math.IsNaN(want):
+				want = 0
+			case math.IsInf(want, 0):
+				want = math.Copysign(math.MaxFloat64, want)
+			}
+			if h != want {
+				t.Errorf("height of cell (%d, %d) = %g, want %g", x, y, h, want)
+			}
+		}
+	}
+}
+
+// TestExportProfileCSV exports the center row of a small grid holding a
+// distinct height in every cell. It must write a header and one row per
+// column, each holding the height of its cell on that row exactly.
+func TestExportProfileCSV(t *testing.T) {
+	const width, height = 9, 5
+	wg := newSmallGrid(width, height)
+	heights := wg.Heights()
+	for i := range heights {
+		heights[i] = float64(i)/7 - 3
+	}
+
+	var buf bytes.Buffer
+	if err := wg.ExportProfileCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != width+1 {
+		t.Fatalf("exported %d rows, want a header and %d", len(rows), width)
+	}
+	if !slices.Equal(rows[0], []string{"x", "height"}) {
+		t.Errorf("header = %q, want x,height", rows[0])
+	}
+	for x, row := range rows[1:] {
+		h, err := strconv.ParseFloat(row[1], 64)
+		if err != nil {
+			t.Fatalf("row %d: %v", x, err)
+		}
+		if row[0] != strconv.Itoa(x) || h != heights[wg.Index(x, height/2)] {
+			t.Errorf("row %d = %q, want %d,%g", x, row, x, heights[wg.Index(x, height/2)])
+		}
+	}
+}
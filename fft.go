@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// fft transforms a in place with an iterative radix-2 Cooley-Tukey FFT.
+// len(a) must be a power of two. The inverse transform includes the 1/n
+// normalization, so fft(a, true) undoes fft(a, false).
+func fft(a []complex128, inverse bool) {
+	n := len(a)
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range a {
+		if j := int(bits.Reverse64(uint64(i)) >> shift); i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := a[start+k], a[start+k+size/2]*w
+				a[start+k], a[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+
+	if inverse {
+		for i := range a {
+			a[i] /= complex(float64(n), 0)
+		}
+	}
+}
+
+// fft2 transforms a w×h row-major grid in place, rows then columns. column
+// is scratch space of at least h elements.
+func fft2(a []complex128, w, h int, inverse bool, column []complex128) {
+	for y := 0; y < h; y++ {
+		fft(a[y*w:(y+1)*w], inverse)
+	}
+	column = column[:h]
+	for x := 0; x < w; x++ {
+		for y := range column {
+			column[y] = a[y*w+x]
+		}
+		fft(column, inverse)
+		for y := range column {
+			a[y*w+x] = column[y]
+		}
+	}
+}
+
+// isPowerOfTwo reports whether n is a positive power of two.
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
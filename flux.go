@@ -0,0 +1,49 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	fluxBlock       = 16   // cells per side averaged into one arrow
+	fluxArrowLength = 24.0 // screen pixels of the strongest arrow
+	fluxMinShare    = 0.05 // arrows weaker than this share of the strongest are left out
+)
+
+// drawFlux draws the energy flux as arrows, scaled so the strongest one is
+// fluxArrowLength long.
+func (g *Game) drawFlux(screen *ebiten.Image) {
+	g.flux = g.waveGrid.EnergyFlux(fluxBlock, g.flux[:0])
+	strongest := 0.0
+	for _, a := range g.flux {
+		strongest = math.Max(strongest, math.Hypot(a.FX, a.FY))
+	}
+	if strongest == 0 {
+		return
+	}
+
+	c := color.RGBA{255, 255, 255, 200}
+	for _, a := range g.flux {
+		magnitude := math.Hypot(a.FX, a.FY)
+		if magnitude < fluxMinShare*strongest {
+			continue
+		}
+		length := fluxArrowLength * magnitude / strongest
+		ux, uy := a.FX/magnitude, a.FY/magnitude
+		x, y := g.gridToScreen(a.X, a.Y)
+		// Center the arrow on the block, with a head of two short strokes
+		x0, y0 := x-ux*length/2, y-uy*length/2
+		x1, y1 := x+ux*length/2, y+uy*length/2
+		head := math.Min(6, length/2)
+		vector.StrokeLine(screen, float32(x0), float32(y0), float32(x1), float32(y1), 1, c, true)
+		for _, side := range []float64{-1, 1} {
+			hx := x1 - head*(ux+side*uy*0.5)
+			hy := y1 - head*(uy-side*ux*0.5)
+			vector.StrokeLine(screen, float32(x1), float32(y1), float32(hx), float32(hy), 1, c, true)
+		}
+	}
+}
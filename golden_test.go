@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden field hash in testdata from the current solver")
+
+// The golden run: a fixed sequence of impulses on the default pond whose
+// final field hash is pinned in goldenFile.
+const goldenSteps = 300
+
+var goldenImpulses = []Impulse{
+	{0, 500, 300, defaultBrushRadius},
+	{40, 430, 260, defaultBrushRadius},
+	{90, 560, 340, defaultBrushRadius},
+}
+
+// goldenFile holds the FieldHash of the golden run. When a change to the
+// solver is intentional, regenerate it with
+//
+//	go test -run GoldenFieldHash -update
+var goldenFile = filepath.Join("testdata", "golden_hash.txt")
+
+// fusingArch reports whether Go may fuse multiply-adds on this architecture,
+// which rounds differently from the separate operations the golden hash was
+// taken with.
+func fusingArch() bool {
+	switch runtime.GOARCH {
+	case "arm64", "loong64", "ppc64", "ppc64le", "riscv64", "s390x":
+		return true
+	}
+	return false
+}
+
+// TestGoldenFieldHash runs the golden sequence and compares the hash of the
+// field with the one in goldenFile, catching any change to the solver
+// numerics.
+func TestGoldenFieldHash(t *testing.T) {
+	if fusingArch() {
+		t.Skipf("%s may fuse multiply-adds, so the field differs in the last bits", runtime.GOARCH)
+	}
+	h := runHeadlessGrid(DefaultConfig(), goldenSteps, goldenImpulses).FieldHash()
+	if *update {
+		if err := os.WriteFile(goldenFile, []byte("0x"+strconv.FormatUint(h, 16)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := strconv.ParseUint(strings.TrimSpace(string(data)), 0, 64)
+	if err != nil {
+		t.Fatalf("%s: %v", goldenFile, err)
+	}
+	if h != golden {
+		t.Fatalf("field hash after %d steps = %#016x, golden %#016x: the solver numerics changed; if that is intended, rerun with -update", goldenSteps, h, golden)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	graphWidth   = 200
+	graphHeight  = 60
+	graphMargin  = 10
+	graphSamples = 300 // frames of history kept, 5 s at 60 TPS
+)
+
+// ringBuffer keeps the last len(values) samples pushed into it.
+type ringBuffer struct {
+	values []float64
+	next   int // index the next sample is written to
+	count  int
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{values: make([]float64, size)}
+}
+
+func (r *ringBuffer) push(v float64) {
+	r.values[r.next] = v
+	r.next = (r.next + 1) % len(r.values)
+	r.count = min(r.count+1, len(r.values))
+}
+
+// at returns the i-th kept sample, oldest first.
+func (r *ringBuffer) at(i int) float64 {
+	start := (r.next - r.count + len(r.values)) % len(r.values)
+	return r.values[(start+i)%len(r.values)]
+}
+
+// max and min return the extremes of the kept samples, never beyond 0 so the
+// graph range always includes zero.
+func (r *ringBuffer) max() float64 {
+	m := 0.0
+	for i := range r.count {
+		m = max(m, r.at(i))
+	}
+	return m
+}
+
+func (r *ringBuffer) min() float64 {
+	m := 0.0
+	for i := range r.count {
+		m = min(m, r.at(i))
+	}
+	return m
+}
+
+// drawGraph plots the history as a line graph in the bottom-right corner,
+// scaled so its samples span the full height. Slot 0 is the bottom graph and
+// each further slot stacks one graph above. Signed histories get a zero line.
+func drawGraph(screen *ebiten.Image, label string, history *ringBuffer, slot int) {
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	left := float32(w - graphWidth - graphMargin)
+	bottom := float32(h - graphMargin - slot*(graphHeight+graphMargin))
+
+	vector.FillRect(screen, left, bottom-graphHeight, graphWidth, graphHeight, color.RGBA{0, 0, 0, 160}, false)
+
+	lo, hi := history.min(), history.max()
+	if history.count > 1 && hi > lo {
+		toY := func(v float64) float32 {
+			return bottom - float32((v-lo)/(hi-lo))*graphHeight
+		}
+		if lo < 0 {
+			vector.StrokeLine(screen, left, toY(0), left+graphWidth, toY(0), 1, color.RGBA{90, 90, 90, 255}, false)
+		}
+		step := float32(graphWidth) / float32(len(history.values)-1)
+		for i := 1; i < history.count; i++ {
+			vector.StrokeLine(screen, left+float32(i-1)*step, toY(history.at(i-1)), left+float32(i)*step, toY(history.at(i)), 1, color.RGBA{120, 220, 255, 255}, false)
+		}
+	}
+
+	latest := 0.0
+	if history.count > 0 {
+		latest = history.at(history.count - 1)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%s: %.2f", label, latest), int(left)+4, int(bottom-graphHeight))
+}
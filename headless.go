@@ -0,0 +1,40 @@
+package main
+
+import "game/wavegrid"
+
+// Impulse is a disturbance applied to a headless run at the start of a given
+// step, with the brush radius in cells of AddWave.
+type Impulse struct {
+	step         int
+	x, y, radius float64
+}
+
+// RunHeadless simulates the pond of cfg for steps time steps without opening
+// a window, applying each impulse at its step, and returns the final heights.
+func RunHeadless(cfg Config, steps int, sources []Impulse) [][]float64 {
+	wg := runHeadlessGrid(cfg, steps, sources)
+	width, height := wg.Size()
+	heights := make([][]float64, height)
+	for y := range heights {
+		heights[y] = wg.Heights()[y*width : (y+1)*width]
+	}
+	return heights
+}
+
+// runHeadlessGrid is RunHeadless returning the whole grid.
+func runHeadlessGrid(cfg Config, steps int, sources []Impulse) *wavegrid.Grid {
+	shapes, err := configShapes(cfg)
+	if err != nil {
+		panic(err)
+	}
+	wg := wavegrid.New(cfg.gridParams(), shapes...)
+	for step := 0; step < steps; step++ {
+		for _, imp := range sources {
+			if imp.step == step {
+				wg.AddWave(imp.x, imp.y, imp.radius)
+			}
+		}
+		wg.Step()
+	}
+	return wg
+}
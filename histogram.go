@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const histogramBins = 41 // odd, so still water falls in the middle bin
+
+// Histogram counts heights into equal bins over [-limit, limit]. Heights at
+// or past the limit go in the end bins and are counted as saturated, so tall
+// end bars show the field hitting the clamp or the ends of the color map.
+type Histogram struct {
+	counts    []int // reused every frame
+	limit     float64
+	total     int
+	saturated int
+}
+
+func NewHistogram(bins int) *Histogram {
+	return &Histogram{counts: make([]int, bins)}
+}
+
+// reset empties the bins and sets the range of the next batch of heights.
+func (h *Histogram) reset(limit float64) {
+	clear(h.counts)
+	h.limit = limit
+	h.total, h.saturated = 0, 0
+}
+
+func (h *Histogram) add(v float64) {
+	h.total++
+	if math.Abs(v) >= h.limit {
+		h.saturated++
+	}
+	bin := int((v/h.limit + 1) / 2 * float64(len(h.counts)))
+	h.counts[max(0, min(len(h.counts)-1, bin))]++
+}
+
+// draw shows the bins as bars in the graph stack of drawGraph. Bar heights
+// are logarithmic, since still water dwarfs every other bin.
+func (h *Histogram) draw(screen *ebiten.Image, label string, slot int) {
+	w, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	left := float32(w - graphWidth - graphMargin)
+	bottom := float32(sh - graphMargin - slot*(graphHeight+graphMargin))
+
+	vector.FillRect(screen, left, bottom-graphHeight, graphWidth, graphHeight, color.RGBA{0, 0, 0, 160}, false)
+
+	peak := 0
+	for _, c := range h.counts {
+		peak = max(peak, c)
+	}
+	if peak > 0 {
+		barWidth := float32(graphWidth) / float32(len(h.counts))
+		for i, c := range h.counts {
+			barColor := color.RGBA{120, 220, 255, 255}
+			if i == 0 || i == len(h.counts)-1 {
+				barColor = color.RGBA{255, 110, 90, 255}
+			}
+			barHeight := float32(math.Log1p(float64(c))/math.Log1p(float64(peak))) * (graphHeight - 14)
+			vector.FillRect(screen, left+float32(i)*barWidth, bottom-barHeight, barWidth-1, barHeight, barColor, false)
+		}
+	}
+
+	share := 0.0
+	if h.total > 0 {
+		share = 100 * float64(h.saturated) / float64(h.total)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%s ±%.2f: %.1f%% saturated", label, h.limit, share), int(left)+4, int(bottom-graphHeight))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"game/wavegrid"
+)
+
+const (
+	labelCharWidth  = 6 // debug font glyph size
+	labelCharHeight = 16
+)
+
+// Label is a piece of text pinned to a point of the pond for annotated screenshots.
+type Label struct {
+	x, y float64 // grid coordinates, so labels stay put under the zoom transform
+	text string
+
+	// A label placed on a continuous source follows it, dx, dy from it
+	pin    *wavegrid.ContinuousSource
+	dx, dy float64
+}
+
+// newLabel returns an empty label at the grid position (x, y), anchored to
+// the continuous source there if there is one.
+func (g *Game) newLabel(x, y float64) Label {
+	l := Label{x: x, y: y}
+	for _, p := range g.waveGrid.Pins {
+		if math.Hypot(p.X-x, p.Y-y) <= pinGrab {
+			l.pin, l.dx, l.dy = p, x-p.X, y-p.Y
+			break
+		}
+	}
+	return l
+}
+
+// followPins moves anchored labels along with their sources, and lets go of
+// the sources that were removed, leaving the label where it was.
+func (g *Game) followPins() {
+	for i := range g.labels {
+		l := &g.labels[i]
+		if l.pin == nil {
+			continue
+		}
+		if !slices.Contains(g.waveGrid.Pins, l.pin) {
+			l.pin = nil
+			continue
+		}
+		l.x, l.y = l.pin.X+l.dx, l.pin.Y+l.dy
+	}
+}
+
+// editingLabel reports whether keystrokes are currently going into a label.
+func (g *Game) editingLabel() bool {
+	return g.labelMode && g.editing >= 0
+}
+
+// labelAt returns the index of the label under the screen point, or -1.
+func (g *Game) labelAt(sx, sy int) int {
+	for i := len(g.labels) - 1; i >= 0; i-- {
+		lx, ly := g.gridToScreen(g.labels[i].x, g.labels[i].y)
+		w := max(1, len(g.labels[i].text)) * labelCharWidth
+		if sx >= int(lx) && sx < int(lx)+w && sy >= int(ly) && sy < int(ly)+labelCharHeight {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Game) removeLabel(i int) {
+	g.labels = append(g.labels[:i], g.labels[i+1:]...)
+	g.editing = -1
+}
+
+// updateLabels handles placing (left click), editing (typing) and removing
+// (right click or Delete) labels while label mode is on.
+func (g *Game) updateLabels() {
+	sx, sy := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		g.editing = g.labelAt(sx, sy)
+		if g.editing < 0 {
+			gx, gy := g.screenToGrid(sx, sy)
+			g.labels = append(g.labels, g.newLabel(gx, gy))
+			g.editing = len(g.labels) - 1
+		}
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		if i := g.labelAt(sx, sy); i >= 0 {
+			g.removeLabel(i)
+		}
+	}
+
+	if g.editing < 0 {
+		return
+	}
+
+	l := &g.labels[g.editing]
+	g.inputChars = ebiten.AppendInputChars(g.inputChars[:0])
+	l.text += string(g.inputChars)
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(l.text) > 0 {
+		runes := []rune(l.text)
+		l.text = string(runes[:len(runes)-1])
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDelete) {
+		g.removeLabel(g.editing)
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		if l.text == "" {
+			g.removeLabel(g.editing)
+			return
+		}
+		g.editing = -1
+	}
+}
+
+func (g *Game) drawLabels(screen *ebiten.Image) {
+	for i, l := range g.labels {
+		sx, sy := g.gridToScreen(l.x, l.y)
+		text := l.text
+		if g.editingLabel() && i == g.editing {
+			text += "_"
+		}
+		ebitenutil.DebugPrintAt(screen, text, int(sx), int(sy))
+	}
+}
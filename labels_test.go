@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"game/wavegrid"
+)
+
+// TestLabelFollowsPin places a label on a continuous source, moves the source
+// and then removes it. The label must keep its offset from the source while
+// it exists, and stay where it was once it is gone.
+func TestLabelFollowsPin(t *testing.T) {
+	g := newTestGame(t)
+	wg := g.waveGrid
+	cx, cy := wg.Center()
+	pin := wavegrid.NewContinuousSource(cx, cy, pinRadius, pinFrequency, pinAmplitude, g.timestep.dt)
+	wg.Pins = append(wg.Pins, pin)
+
+	g.labels = append(g.labels, g.newLabel(cx+3, cy-2), g.newLabel(cx+100, cy))
+	if g.labels[0].pin != pin || g.labels[1].pin != nil {
+		t.Fatal("only the label placed on the source should follow it")
+	}
+
+	pin.X, pin.Y = pin.X+40, pin.Y+10
+	g.followPins()
+	if l := g.labels[0]; l.x != cx+43 || l.y != cy+8 {
+		t.Errorf("anchored label at (%g, %g), want (%g, %g)", l.x, l.y, cx+43, cy+8)
+	}
+	if l := g.labels[1]; l.x != cx+100 || l.y != cy {
+		t.Errorf("free label moved to (%g, %g)", l.x, l.y)
+	}
+
+	wg.Pins = nil
+	g.followPins()
+	if l := g.labels[0]; l.pin != nil || l.x != cx+43 || l.y != cy+8 {
+		t.Errorf("label of a removed source at (%g, %g), anchored %v; want it left at (%g, %g)", l.x, l.y, l.pin != nil, cx+43, cy+8)
+	}
+}
@@ -1,325 +1,1062 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image"
 	"image/color"
+	"log"
 	"math"
-	"math/rand/v2"
-	"time"
+	"os"
+	"runtime/pprof"
+	"slices"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"game/wavegrid"
 )
 
 const (
-	screenWidth          = 1000
-	screenHeight         = 600
-	gridSize             = 1
-	gridWidth            = screenWidth / gridSize
-	gridHeight           = screenHeight / gridSize
-	waveSpeed            = 0.5
-	damping              = 1
-	updateSteps          = 5
-	generateInitial      = false
-	generateInitialNoise = true
-	zoomScale            = 2.0
+	minDamping           = 0.9
+	maxDamping           = 1
+	waveSpeedStep        = 0.05
+	dampingStep          = 0.001
+	anisotropyStep       = 1.25 // factor per Shift+arrow press
+	maxAnisotropy        = 4.0  // and 1/maxAnisotropy the other way
+	reflectivityStep     = 0.1
+	transmissionStep     = 0.05
+	slowRegionRadius     = 30.0
+	slowRegionFactor     = 0.5 // speed inside painted slow regions, relative to open water
+	minNormValue         = 1.0 // keeps a quiet field from being blown up to full brightness
+	defaultBrushRadius   = 8.0 // impulse radius in cells
+	minBrushRadius       = 1.0
+	maxBrushRadius       = 40.0
+	obstacleBrushRadius  = 4 // cells carved per drag position
+	obstacleGrab         = 3 // cells from a wall an Alt+click still toggles it
+	interfaceBrushRadius = 1 // cells painted per drag position, a thin plate
+
+	defaultPaddleFrequency = 6.0 // Hz, about 15-cell waves at the default speed
+	minPaddleFrequency     = 1.0
+	maxPaddleFrequency     = 20.0
+	paddleFrequencyStep    = 1.0
+	defaultPaddleAmplitude = 8.0
+	minPaddleAmplitude     = 1.0
+	maxPaddleAmplitude     = 20.0
+	minPaddleWavelength    = 5.0 // cells, the range of the panel slider
+	maxPaddleWavelength    = 90.0
+
+	morphDuration = 8.0 // simulated seconds for the pond to morph from circle to square
+
+	maxPins      = 8   // continuous sources; adding another drops the oldest
+	pinFrequency = 4.0 // Hz
+	pinAmplitude = 0.5 // velocity added per step at the peak of the cycle
+	pinRadius    = 3.0 // cells
+	pinGrab      = 8.0 // pixels around a pin that Shift+click removes it from
+
+	fingerDepth = 20.0 // height a held finger pins the water to under its center
+
+	seedAmplitude = 20.0 // height of white in an image seeded with F8
+
+	minGridSize = 1 // pixels per cell, the finest grid + and - step between
+	maxGridSize = 8
 )
 
-type WaveGrid struct {
-	height   [][]float64
-	velocity [][]float64
-	mask     [][]bool
-	shape    []Vector2
-	cx, cy   float64
-	radius   float64
+type Game struct {
+	cfg         Config
+	waveGrid    *WaveGrid
+	renderer    fieldRenderer
+	view        view    // zoom and pan of the field on screen
+	normLocked  bool    // when set, normValue is frozen instead of following the field
+	normValue   float64 // height mapped to full color intensity
+	frame       int
+	paused      bool
+	recorder    *Recorder
+	timestep    fixedTimestep
+	simTime     float64     // seconds of simulated time
+	brushRadius float64     // radius in cells of the impulse a click makes, set with the wheel
+	probe       *AudioProbe // nil unless audio is enabled
+	heightProbe *HeightProbe
+	histogram   *Histogram // nil while hidden
+	showFlux    bool
+	flux        []wavegrid.Flux  // reused buffer for the flux arrows
+	touchIDs    []ebiten.TouchID // reused buffer for new touches
+	rain        *Rain            // nil unless cfg.RainMode
+	held        map[int]float64  // cells pinned by a finger while D or U and the mouse are held
+	morphing    float64          // direction the pond is morphing in, +1 toward the square, -1 back, 0 when stopped
+
+	stepCount int            // solver steps since the pond was last reset
+	events    []Impulse      // clicks since the pond was last reset, see addWave
+	replay    []Impulse      // events from eventsFile still to be injected
+	blowups   int            // times the field went unstable and was reset
+	stats     wavegrid.Stats // measured once per frame, for the color range and the overlay
+	reversal  Reversal       // recent fields, to run the waves back with Backspace
+	tour      *Tour          // nil unless the guided tour is playing, see startTour
+	tir       *TIR           // nil unless the total internal reflection preset is running, see startTIR
+	panel     *ControlPanel  // sliders for the medium and the paddle, nil while hidden
+
+	paddleFrequency float64 // Hz and height of the paddle W puts down, set with Q/E or the panel
+	paddleAmplitude float64
+
+	windowWidth, windowHeight int // window size Layout was last given, which Update resizes the grid to
+
+	labels     []Label
+	labelMode  bool // clicks place and edit labels instead of making waves
+	editing    int  // index of the label receiving typed text, or -1
+	inputChars []rune
+}
+
+func NewGame(cfg Config) (*Game, error) {
+	shapes, err := configShapes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var rain *Rain
+	if cfg.RainMode {
+		rain = NewRain(cfg.RainSeed, cfg.stepTime())
+	}
+	return &Game{
+		cfg:         cfg,
+		rain:        rain,
+		waveGrid:    NewWaveGrid(cfg, shapes...),
+		view:        centeredView(cfg.ZoomScale, float64(cfg.ScreenWidth)/2, float64(cfg.ScreenHeight)/2),
+		normValue:   minNormValue,
+		brushRadius: defaultBrushRadius,
+		heightProbe: NewHeightProbe(),
+
+		paddleFrequency: defaultPaddleFrequency,
+		paddleAmplitude: defaultPaddleAmplitude,
+		editing:         -1,
+		recorder:        NewRecorder(gifFrameSkip),
+		timestep:        fixedTimestep{dt: cfg.stepTime()},
+	}, nil
 }
 
-type Vector2 struct {
-	x, y float64
+// screenToGrid maps a screen position back through the view transform used by draw.
+func (g *Game) screenToGrid(x, y int) (float64, float64) {
+	return g.view.toWorld(float64(x), float64(y))
 }
 
-func NewWaveGrid() *WaveGrid {
-	wg := &WaveGrid{
-		height:   make([][]float64, gridHeight),
-		velocity: make([][]float64, gridHeight),
-		mask:     make([][]bool, gridHeight),
-		cx:       float64(screenWidth) / 2,
-		cy:       float64(screenHeight) / 2,
-		radius:   150.0,                                                   // Keep original
-		shape:    generateCircleShape(screenWidth/2, screenHeight/2, 150), // Keep original
-	}
-
-	for i := range wg.height {
-		wg.height[i] = make([]float64, gridWidth)
-		wg.velocity[i] = make([]float64, gridWidth)
-		wg.mask[i] = make([]bool, gridWidth)
-	}
-
-	wg.initializeMask()
-	distance := 50.0
-	_ = distance
-	salts := [8]float64{}
-	if generateInitialNoise {
-		for i := range 8 {
-			salt := float64(randRange(1, 45))
-			if randRange(1, 2)%2 == 0 {
-				salt = salt * -1
-			}
-			salts[i] = salt
-		}
-	}
-
-	wg.initializeMask()
-	// if generateInitial {
-	// 	wg.addWave(wg.cx, wg.cy)
-	// 	wg.addWave(wg.cx-distance+salts[0], wg.cy-distance+salts[1])
-	// 	wg.addWave(wg.cx-distance+salts[2], wg.cy+distance+salts[3])
-	// 	wg.addWave(wg.cx+distance+salts[4], wg.cy-distance+salts[5])
-	// 	wg.addWave(wg.cx+distance+salts[6], wg.cy+distance+salts[7])
-	// }
-
-	if generateInitial {
-		go func() {
-			n := 1_000_000
-			n = 100_000
-			n = 10_000
-			n = 1_000
-			// n = 500
-			n = 100
-			n = 69
-			n = 42
-			// n = 30
-			// n = 10
-			for range n {
-				wg.addWave(wg.cx, wg.cy)
-				time.Sleep(1 * time.Millisecond)
-			}
-		}()
-	}
-	return wg
+// gridToScreen is the inverse of screenToGrid.
+func (g *Game) gridToScreen(x, y float64) (float64, float64) {
+	return g.view.toScreen(x, y)
 }
 
-func generateCircleShape(cx, cy, radius float64) []Vector2 {
-	var shape []Vector2
-	segments := 200
-	for i := 0; i < segments; i++ {
-		angle := (float64(i) / float64(segments)) * 2 * math.Pi
-		x := cx + radius*math.Cos(angle)
-		y := cy + radius*math.Sin(angle)
-		shape = append(shape, Vector2{x, y})
+func (g *Game) Update() error {
+	if g.windowWidth > 0 && (g.windowWidth != g.cfg.ScreenWidth || g.windowHeight != g.cfg.ScreenHeight) {
+		g.resize(g.windowWidth, g.windowHeight)
 	}
-	return shape
-}
 
-func (wg *WaveGrid) initializeMask() {
-	for y := 0; y < gridHeight; y++ {
-		for x := 0; x < gridWidth; x++ {
-			dx := float64(x) - wg.cx
-			dy := float64(y) - wg.cy
-			dist := math.Sqrt(dx*dx + dy*dy)
-			wg.mask[y][x] = dist < wg.radius
-		}
+	// The tour and the total internal reflection preset set up the pond
+	// themselves, so clicks and shortcuts wait until Esc hands it back
+	if g.tour != nil {
+		g.updateTour()
+	} else if g.tir != nil {
+		g.updateTIR()
 	}
-}
+	touring := g.tour != nil || g.tir != nil
 
-func (wg *WaveGrid) addWave(mx, my float64) {
-	gridX := int(mx)
-	gridY := int(my)
-
-	// Add impulse with smooth falloff
-	radius := 8.0
-	for dy := -int(radius); dy <= int(radius); dy++ {
-		for dx := -int(radius); dx <= int(radius); dx++ {
-			x := gridX + dx
-			y := gridY + dy
-			if x >= 0 && x < gridWidth && y >= 0 && y < gridHeight && wg.mask[y][x] {
-				dist := math.Sqrt(float64(dx*dx + dy*dy))
-				if dist <= radius {
-					// Impulse to velocity (not height directly)
-					energy := 40.0 * (1 - dist/radius) * (1 - dist/radius)
-					wg.velocity[y][x] += energy
-				}
+	// Clicks on the panel move its sliders instead of making waves
+	onPanel := false
+	if g.panel != nil {
+		onPanel = g.panel.update()
+	}
+
+	holding := false
+	if g.labelMode {
+		g.updateLabels()
+	} else if !touring && !onPanel && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		gridX, gridY := g.screenToGrid(x, y)
+		if ebiten.IsKeyPressed(ebiten.KeyO) {
+			// Hold O and drag to carve walls into the pond, each drag a wall
+			// of its own
+			wg := g.waveGrid
+			if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) || inpututil.IsKeyJustPressed(ebiten.KeyO) {
+				wg.BeginObstacle()
+			}
+			wg.SetObstacle(int(gridX/wg.CellSize()), int(gridY/wg.CellSize()), obstacleBrushRadius)
+		} else if ebiten.IsKeyPressed(ebiten.KeyAlt) {
+			// Alt+click a wall to open or close it while the waves keep going
+			if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+				g.toggleObstacle(gridX, gridY)
+			}
+		} else if ebiten.IsKeyPressed(ebiten.KeyI) {
+			// Hold I and drag to paint a partly transparent interface, Shift+I to erase it
+			wg := g.waveGrid
+			wg.SetInterface(int(gridX/wg.CellSize()), int(gridY/wg.CellSize()), interfaceBrushRadius, !ebiten.IsKeyPressed(ebiten.KeyShift))
+		} else if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+				g.togglePin(gridX, gridY)
 			}
+		} else if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyU) {
+			// Hold D and press to push a finger into the water, U to pull it
+			// up. It follows the cursor, and letting go sends out a ring
+			depth := -fingerDepth
+			if ebiten.IsKeyPressed(ebiten.KeyU) {
+				depth = fingerDepth
+			}
+			g.held = g.waveGrid.HoldFinger(gridX, gridY, g.brushRadius, depth, g.held)
+			holding = true
+		} else if sx, sy, ok := g.waveGrid.SnapToMask(gridX, gridY, edgeTolerance); ok {
+			g.addWave(sx, sy)
 		}
 	}
-}
 
-func (wg *WaveGrid) update() {
-	// Apply velocity to height
-	for y := 0; y < gridHeight; y++ {
-		for x := 0; x < gridWidth; x++ {
-			if wg.mask[y][x] {
-				wg.height[y][x] += wg.velocity[y][x]
+	if holding {
+		g.waveGrid.Held = g.held
+	} else {
+		g.waveGrid.Held = nil
+	}
+
+	// Each new touch makes one impulse; unlike the mouse, a held finger doesn't repeat it
+	if !g.labelMode && !touring {
+		g.touchIDs = inpututil.AppendJustPressedTouchIDs(g.touchIDs[:0])
+		for _, id := range g.touchIDs {
+			gridX, gridY := g.screenToGrid(ebiten.TouchPosition(id))
+			if sx, sy, ok := g.waveGrid.SnapToMask(gridX, gridY, edgeTolerance); ok {
+				g.addWave(sx, sy)
 			}
 		}
 	}
 
-	// Calculate new velocities using wave equation
-	newVelocity := make([][]float64, gridHeight)
-	for i := range newVelocity {
-		newVelocity[i] = make([]float64, gridWidth)
+	// Right click moves the audio probe, except in label mode where it removes labels
+	if g.probe != nil && !g.labelMode && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		x, y := ebiten.CursorPosition()
+		g.probe.x, g.probe.y = g.screenToGrid(x, y)
+	}
+
+	g.heightProbe.follow(g.screenToGrid(ebiten.CursorPosition()))
+	g.followPins()
+
+	// The wheel zooms; with Ctrl it picks small sharp ripples or broad swells
+	g.view.update()
+	if _, wheelY := ebiten.Wheel(); wheelY != 0 && ebiten.IsKeyPressed(ebiten.KeyControl) {
+		g.brushRadius = math.Max(minBrushRadius, math.Min(maxBrushRadius, g.brushRadius+wheelY))
 	}
 
-	for y := 1; y < gridHeight-1; y++ {
-		for x := 1; x < gridWidth-1; x++ {
-			if !wg.mask[y][x] {
-				newVelocity[y][x] = 0
+	// Keys type into the label being edited instead of triggering shortcuts
+	if !g.editingLabel() && !touring {
+		if ebiten.IsKeyPressed(ebiten.KeyControl) {
+			// Ctrl+R starts over from a fresh pond
+			if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+				if err := g.freshPond(); err != nil {
+					log.Printf("resetting the pond: %v", err)
+				}
+			}
+		} else if ebiten.IsKeyPressed(ebiten.KeyR) {
+			// Shift+R also takes out the walls and slow regions
+			if ebiten.IsKeyPressed(ebiten.KeyShift) && inpututil.IsKeyJustPressed(ebiten.KeyR) {
+				g.waveGrid.ClearObstacles()
+				g.waveGrid.ClearSpeedRegions()
+			}
+			g.waveGrid.ClearField()
+			g.reversal.reset(g.waveGrid.Grid)
+		}
+
+		// Backspace runs the waves backward, so scattered ripples gather back
+		// into the clicks that made them, until pressed again or out of history
+		if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
+			g.reversal.reversing = !g.reversal.reversing
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+			g.paused = !g.paused
+		}
+
+		// Tune the medium live, clamped to the range where the solver stays
+		// stable. With Ctrl the arrows pan the view instead, and with Shift
+		// Left/Right make the waves faster across than down
+		wg := g.waveGrid
+		if ebiten.IsKeyPressed(ebiten.KeyShift) && !ebiten.IsKeyPressed(ebiten.KeyControl) {
+			ratio := wg.Anisotropy()
+			if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+				ratio *= anisotropyStep
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+				ratio /= anisotropyStep
+			}
+			ratio = math.Max(1/maxAnisotropy, math.Min(maxAnisotropy, ratio))
+			if math.Abs(ratio-1) < 1e-9 {
+				ratio = 1 // back to the exact isotropic solver
+			}
+			wg.SetAnisotropy(ratio)
+			g.cfg.Anisotropy = ratio
+		} else if !ebiten.IsKeyPressed(ebiten.KeyControl) {
+			if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+				wg.SetWaveSpeed(wg.WaveSpeed() + waveSpeedStep)
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+				wg.SetWaveSpeed(wg.WaveSpeed() - waveSpeedStep)
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+				wg.SetDamping(math.Min(maxDamping, wg.Damping()+dampingStep))
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+				wg.SetDamping(math.Max(minDamping, wg.Damping()-dampingStep))
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key0) {
+			cx, cy := wg.Center()
+			g.view = centeredView(g.cfg.ZoomScale, cx, cy)
+		}
+
+		// Lock the exposure at the current normalization, like a camera's AE-lock
+		if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+			g.normLocked = !g.normLocked
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+			name, err := g.waveGrid.saveMaskPNG(g.frame)
+			if err != nil {
+				log.Printf("saving mask: %v", err)
+			} else {
+				log.Printf("mask saved to %s", name)
+			}
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			name, err := savePNG("wave", g.waveGrid.renderToImage(g.normValue))
+			if err != nil {
+				log.Printf("saving frame: %v", err)
+			} else {
+				log.Printf("frame saved to %s", name)
+			}
+		}
+
+		// J exports the whole field, Shift+J the profile along the center row
+		if inpututil.IsKeyJustPressed(ebiten.KeyJ) && ebiten.IsKeyPressed(ebiten.KeyShift) {
+			name, err := g.waveGrid.saveProfileCSV()
+			if err != nil {
+				log.Printf("exporting profile: %v", err)
+			} else {
+				log.Printf("profile exported to %s", name)
+			}
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyJ) {
+			name, err := g.waveGrid.saveFieldJSON()
+			if err != nil {
+				log.Printf("exporting field: %v", err)
+			} else {
+				log.Printf("field exported to %s", name)
+			}
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+			x, y := ebiten.CursorPosition()
+			gridX, gridY := g.screenToGrid(x, y)
+			wg.PaintSpeedCircle(gridX, gridY, slowRegionRadius, slowRegionFactor)
+		}
+
+		// B flips between a hard wall and a fully absorbing edge, PgUp/PgDn go between
+		if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+			if wg.Reflectivity < 1 {
+				wg.Reflectivity = 1
+			} else {
+				wg.Reflectivity = 0
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyPageUp) {
+			wg.Reflectivity = stepFraction(wg.Reflectivity, reflectivityStep)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyPageDown) {
+			wg.Reflectivity = stepFraction(wg.Reflectivity, -reflectivityStep)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyRightBracket) {
+			wg.Transmission = stepFraction(wg.Transmission, transmissionStep)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyLeftBracket) {
+			wg.Transmission = stepFraction(wg.Transmission, -transmissionStep)
+		}
+
+		// V switches the lighting, Shift+V between showing height and intensity
+		if inpututil.IsKeyJustPressed(ebiten.KeyV) && ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if wg.displayMode == Height {
+				wg.displayMode = Intensity
+			} else {
+				wg.displayMode = Height
+			}
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyV) {
+			if wg.renderMode == FlatColor {
+				wg.renderMode = Shaded
+			} else {
+				wg.renderMode = FlatColor
+			}
+		}
+
+		// 1-4 pick the impulse profile, Shift+1-5 the pond shape
+		for i, key := range []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5} {
+			if !inpututil.IsKeyJustPressed(key) {
 				continue
 			}
+			if ebiten.IsKeyPressed(ebiten.KeyShift) {
+				g.setShape(shapeRegistry[i].name)
+			} else if i <= int(wavegrid.SingleCycle) {
+				wg.Impulse = wavegrid.ImpulseShape(i)
+			}
+		}
 
-			// Laplacian of height
-			laplacian := 0.0
-			neighbors := 0
+		updateTheme()
+		if inpututil.IsKeyJustPressed(ebiten.KeyK) && !ebiten.IsKeyPressed(ebiten.KeyShift) {
+			wg.colorMap = nextColorMap(wg.colorMap)
+		}
 
-			// Check 4 neighbors
-			deltas := []struct{ dx, dy int }{
-				{0, -1}, {0, 1}, {-1, 0}, {1, 0},
+		if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+			// Wrapping only does something where the water reaches the
+			// border, so it opens the pond up to the whole screen
+			periodic := !wg.Periodic()
+			if periodic {
+				g.setShape("open")
 			}
-			// Check 8 neighbors (including diagonals)
-			deltas = []struct{ dx, dy int }{
-				{0, -1}, {0, 1}, {-1, 0}, {1, 0},
-				{-1, -1}, {-1, 1}, {1, -1}, {1, 1},
+			wg.SetPeriodic(periodic)
+			g.cfg.Periodic = periodic
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+			wg.SetStencil((wg.Stencil() + 1) % (wavegrid.NinePoint + 1))
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+			wg.showContours = !wg.showContours
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyG) {
+			if g.recorder.recording {
+				g.saveRecording()
+			} else {
+				g.recorder.start()
 			}
+		}
 
-			for _, d := range deltas {
-				nx := x + d.dx
-				ny := y + d.dy
+		if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+			if err := g.saveState(); err != nil {
+				log.Printf("saving state: %v", err)
+			} else {
+				log.Printf("state saved to %s", stateFile)
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+			if err := g.loadState(); err != nil {
+				log.Printf("loading state: %v", err)
+			} else {
+				log.Printf("state loaded from %s", stateFile)
+			}
+		}
 
-				if nx >= 0 && nx < gridWidth && ny >= 0 && ny < gridHeight {
-					if wg.mask[ny][nx] {
-						laplacian += wg.height[ny][nx] - wg.height[y][x]
-					} else {
-						// Boundary: mirror (perfect reflection)
-						laplacian += -wg.height[y][x]
-					}
-				}
-				neighbors++
+		if inpututil.IsKeyJustPressed(ebiten.KeyF8) {
+			if err := wg.SeedFromImage(g.cfg.SeedImage, seedAmplitude); err != nil {
+				log.Printf("seeding from image: %v", err)
+			} else {
+				g.reversal.reset(wg.Grid)
+				log.Printf("field seeded from %s", g.cfg.SeedImage)
 			}
+		}
 
-			laplacian /= float64(neighbors)
+		// F6 saves the clicks since the last reset, F7 replays them on a fresh pond
+		if inpututil.IsKeyJustPressed(ebiten.KeyF6) {
+			if err := g.saveEvents(); err != nil {
+				log.Printf("saving events: %v", err)
+			} else {
+				log.Printf("%d events saved to %s", len(g.events), eventsFile)
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyF7) {
+			if err := g.startReplay(); err != nil {
+				log.Printf("replaying events: %v", err)
+			} else {
+				log.Printf("replaying %d events from %s", len(g.replay), eventsFile)
+			}
+		}
 
-			// Wave acceleration based on Laplacian
-			acceleration := laplacian * waveSpeed * waveSpeed
-			newVelocity[y][x] = (wg.velocity[y][x] + acceleration) * damping
+		if inpututil.IsKeyJustPressed(ebiten.KeyF10) {
+			g.startTour()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+			g.startTIR()
 		}
-	}
 
-	wg.velocity = newVelocity
+		if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+			if g.panel == nil {
+				g.panel = g.newControlPanel()
+			} else {
+				g.panel = nil
+			}
+		}
 
-	// Zero out height at boundaries
-	for x := 0; x < gridWidth; x++ {
-		wg.height[0][x] = 0
-		wg.height[gridHeight-1][x] = 0
-	}
-	for y := 0; y < gridHeight; y++ {
-		wg.height[y][0] = 0
-		wg.height[y][gridWidth-1] = 0
-	}
-}
+		// F11 goes from not tracking the energy to measuring its drift, to
+		// holding it constant, and back
+		if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+			switch {
+			case wg.ConserveEnergy:
+				wg.TrackEnergy, wg.ConserveEnergy = false, false
+			case wg.TrackEnergy:
+				wg.ConserveEnergy = true
+			default:
+				wg.TrackEnergy = true
+			}
+			wg.ResetEnergyDrift()
+		}
 
-func (wg *WaveGrid) draw(screen *ebiten.Image) {
-	screen.Fill(color.RGBA{15, 15, 25, 255})
+		if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+			g.labelMode = !g.labelMode
+			g.editing = -1
+		}
 
-	// Calculate offset to keep center in view when zoomed
-	offsetX := float32((1.0 - zoomScale) * wg.cx)
-	offsetY := float32((1.0 - zoomScale) * wg.cy)
+		if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+			g.heightProbe.frozen = !g.heightProbe.frozen
+		}
 
-	// Draw wave grid
-	for y := 0; y < gridHeight; y++ {
-		for x := 0; x < gridWidth; x++ {
-			if !wg.mask[y][x] {
-				continue
+		if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+			g.showFlux = !g.showFlux
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyY) {
+			if g.histogram == nil {
+				g.histogram = NewHistogram(histogramBins)
+			} else {
+				g.histogram = nil
 			}
+		}
 
-			h := wg.height[y][x]
+		// W puts a paddle along the column under the cursor, Shift+W along the
+		// row, and removes it again; Q/E change its frequency
+		if inpututil.IsKeyJustPressed(ebiten.KeyW) {
+			if wg.Paddle != nil {
+				wg.Paddle = nil
+			} else {
+				gridX, gridY := g.screenToGrid(ebiten.CursorPosition())
+				vertical := !ebiten.IsKeyPressed(ebiten.KeyShift)
+				line := int(gridX / wg.CellSize())
+				if !vertical {
+					line = int(gridY / wg.CellSize())
+				}
+				wg.Paddle = wavegrid.NewPaddle(vertical, line, g.paddleFrequency, g.paddleAmplitude, g.timestep.dt)
+			}
+		}
+		// + makes the cells coarser and faster to simulate, - finer
+		newGridSize := g.cfg.GridSize
+		if inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadAdd) {
+			newGridSize = min(maxGridSize, g.cfg.GridSize+1)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadSubtract) {
+			newGridSize = max(minGridSize, g.cfg.GridSize-1)
+		}
+		if newGridSize != g.cfg.GridSize {
+			if g.recorder.recording {
+				g.saveRecording() // the frames would change size
+			}
+			wg.Resample(newGridSize)
+			g.cfg.GridSize = newGridSize
+		}
 
-			// Clamp and normalize
-			h = math.Max(-80, math.Min(80, h))
-			norm := h / 80.0
+		// Z freezes the field as a faded backdrop and calms the water, to
+		// compare with the pattern of a new source; Z again drops it
+		if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+			if wg.snapshot != nil {
+				wg.snapshot = nil
+			} else {
+				wg.takeSnapshot(g.normValue)
+				wg.ClearField()
+			}
+		}
 
-			var r, g, b uint8
+		// N morphs the pond between a circle and a square and back, pausing
+		// the morph where it is on the next press
+		if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+			switch {
+			case g.morphing != 0:
+				g.morphing = 0
+			case !wg.Morphing():
+				cx, cy := wg.Center()
+				r := g.cfg.ShapeRadius
+				wg.StartMorph(wavegrid.Circle(cx, cy, r), wavegrid.Square(cx, cy, r))
+				g.morphing = 1
+			default:
+				g.morphing = 1
+				if wg.Morph() == 1 {
+					g.morphing = -1
+				}
+			}
+		}
+
+		if wg.Paddle != nil {
+			if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+				g.setPaddleFrequency(wg.Paddle.Frequency + paddleFrequencyStep)
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
+				g.setPaddleFrequency(wg.Paddle.Frequency - paddleFrequencyStep)
+			}
+		}
+	}
 
-			if norm > 0 {
-				// Crest: bright blue
-				b = uint8(150 + norm*100)
-				g = uint8(120 + norm*60)
-				r = uint8(40 + norm*40)
+	if !g.paused {
+		for range g.timestep.steps() {
+			if g.reversal.reversing {
+				g.stepBackOnce(g.timestep.dt)
 			} else {
-				// Trough: darker, reddish
-				r = uint8(100 - norm*80)
-				g = uint8(100 - norm*60)
-				b = uint8(120 - norm*40)
+				g.stepOnce(g.timestep.dt)
+			}
+		}
+		g.frame++
+	} else {
+		g.timestep.reset()
+		if !g.editingLabel() && inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+			// Advance a single solver step while paused
+			g.stepOnce(g.timestep.dt)
+			g.frame++
+		}
+	}
+
+	g.stats = g.waveGrid.Stats()
+	if !g.normLocked {
+		g.normValue = math.Max(minNormValue, g.stats.MaxHeight)
+	}
+
+	// Binned against the color range, so with the exposure locked the end
+	// bars show how much of the pond is drawn at full color
+	if h := g.histogram; h != nil {
+		h.reset(g.normValue)
+		wg := g.waveGrid
+		width, height := wg.Size()
+		for y := range height {
+			for x := range width {
+				if wg.InMask(x, y) {
+					h.add(wg.HeightAt(x, y))
+				}
 			}
+		}
+	}
+
+	if g.probe != nil {
+		g.probe.sample(g.waveGrid, g.normValue)
+	}
 
-			px := offsetX + float32(x*gridSize)*float32(zoomScale)
-			py := offsetY + float32(y*gridSize)*float32(zoomScale)
-			vector.DrawFilledRect(screen, px, py, float32(gridSize)*float32(zoomScale), float32(gridSize)*float32(zoomScale), color.RGBA{r, g, b, 255}, false)
+	if !g.paused {
+		g.recorder.capture(func() *image.RGBA { return g.waveGrid.renderToImage(g.normValue) })
+		if g.recorder.full() {
+			g.saveRecording()
 		}
 	}
+	return nil
+}
 
-	// Draw shape boundary
-	if len(wg.shape) > 1 {
-		for i := 0; i < len(wg.shape)-1; i++ {
-			p1 := wg.shape[i]
-			p2 := wg.shape[i+1]
-			vector.StrokeLine(screen, offsetX+float32(p1.x*zoomScale), offsetY+float32(p1.y*zoomScale), offsetX+float32(p2.x*zoomScale), offsetY+float32(p2.y*zoomScale), 2, color.RGBA{200, 150, 100, 255}, false)
+// togglePin removes the continuous source at the pixel position (x, y), or
+// adds one there if there is none, dropping the oldest past maxPins.
+func (g *Game) togglePin(x, y float64) {
+	wg := g.waveGrid
+	for i, p := range wg.Pins {
+		if math.Hypot(p.X-x, p.Y-y) <= pinGrab {
+			wg.Pins = slices.Delete(wg.Pins, i, i+1)
+			return
 		}
-		// Close the shape
-		p1 := wg.shape[len(wg.shape)-1]
-		p2 := wg.shape[0]
-		vector.StrokeLine(screen, offsetX+float32(p1.x*zoomScale), offsetY+float32(p1.y*zoomScale), offsetX+float32(p2.x*zoomScale), offsetY+float32(p2.y*zoomScale), 2, color.RGBA{200, 150, 100, 255}, false)
 	}
+	x, y, ok := wg.SnapToMask(x, y, edgeTolerance)
+	if !ok {
+		return
+	}
+	if len(wg.Pins) == maxPins {
+		wg.Pins = slices.Delete(wg.Pins, 0, 1)
+	}
+	wg.Pins = append(wg.Pins, wavegrid.NewContinuousSource(x, y, pinRadius, pinFrequency, pinAmplitude, g.timestep.dt))
+}
 
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %.2f\nClick to create waves | Press R to reset", ebiten.CurrentTPS()))
+// toggleObstacle opens or closes the wall nearest the pixel position (x, y),
+// if one is within obstacleGrab cells.
+func (g *Game) toggleObstacle(x, y float64) {
+	wg := g.waveGrid
+	width, height := wg.Size()
+	cx, cy := int(x/wg.CellSize()), int(y/wg.CellSize())
+	id, best := 0, obstacleGrab*obstacleGrab+1
+	for gy := max(0, cy-obstacleGrab); gy <= min(height-1, cy+obstacleGrab); gy++ {
+		for gx := max(0, cx-obstacleGrab); gx <= min(width-1, cx+obstacleGrab); gx++ {
+			d := (gx-cx)*(gx-cx) + (gy-cy)*(gy-cy)
+			if wall := wg.ObstacleID(wg.Index(gx, gy)); wall > 0 && d < best {
+				id, best = wall, d
+			}
+		}
+	}
+	if id > 0 {
+		wg.ToggleObstacle(id)
+	}
 }
 
-type Game struct {
-	waveGrid *WaveGrid
+// setPaddleFrequency sets the frequency of the paddle and of the ones W puts
+// down after it, clamped to the paddle's range.
+func (g *Game) setPaddleFrequency(hz float64) {
+	g.paddleFrequency = math.Max(minPaddleFrequency, math.Min(maxPaddleFrequency, hz))
+	if p := g.waveGrid.Paddle; p != nil {
+		p.Frequency = g.paddleFrequency
+	}
 }
 
-func NewGame() *Game {
-	return &Game{
-		waveGrid: NewWaveGrid(),
+// paddleWaveSpeed returns how fast long waves such as the paddle's cross the
+// open water, in cells per second, so its wavelength is this over its
+// frequency.
+func (g *Game) paddleWaveSpeed() float64 {
+	return g.waveGrid.WaveSpeed() * math.Sqrt(3.0/8.0) / g.timestep.dt
+}
+
+// setShape switches the pond to the registered shape name, keeping the water
+// that is still inside it. A morph in progress is dropped.
+func (g *Game) setShape(name string) {
+	cfg := g.cfg
+	cfg.Shape, cfg.ShapeFile = name, ""
+	shapes, err := configShapes(cfg)
+	if err != nil {
+		log.Printf("switching shape: %v", err)
+		return
 	}
+	g.cfg = cfg
+	g.morphing = 0
+	g.waveGrid.SetShapes(shapes)
 }
 
-func (g *Game) Update() error {
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		x, y := ebiten.CursorPosition()
-		offsetX := (1.0 - zoomScale) * g.waveGrid.cx
-		offsetY := (1.0 - zoomScale) * g.waveGrid.cy
-		gridX := (float64(x) - offsetX) / zoomScale
-		gridY := (float64(y) - offsetY) / zoomScale
-		g.waveGrid.addWave(gridX, gridY)
+// resize rebuilds the pond for a screen of width×height pixels, laid out
+// around its new center, and moves the waves, the view and everything placed
+// on the water along with it, so the same spot stays under the same click.
+func (g *Game) resize(width, height int) {
+	cfg := g.cfg
+	cfg.ScreenWidth, cfg.ScreenHeight = width, height
+	shapes, err := configShapes(cfg)
+	if err != nil {
+		log.Printf("resizing: %v", err)
+		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyR) {
-		g.waveGrid = NewWaveGrid()
+	// Outlines from a file don't follow the screen center
+	dx, dy := float64(width-g.cfg.ScreenWidth)/2, float64(height-g.cfg.ScreenHeight)/2
+	if cfg.ShapeFile != "" {
+		dx, dy = 0, 0
+	}
+	if g.recorder.recording {
+		g.saveRecording() // the frames would change size
 	}
+	wg := g.waveGrid
+	wg.Resize(width, height, shapes, dx, dy)
+	wg.snapshot = nil
+	g.cfg = cfg
+	g.morphing = 0
+	g.held = nil
 
-	for i := 0; i < updateSteps; i++ {
-		g.waveGrid.update()
+	// A grid point p drawn at s moves to p+d, and should be drawn at s+d
+	g.view.offsetX += (1 - g.view.scale) * dx
+	g.view.offsetY += (1 - g.view.scale) * dy
+	for i := range g.labels {
+		g.labels[i].x += dx
+		g.labels[i].y += dy
+	}
+	if g.probe != nil {
+		g.probe.x += dx
+		g.probe.y += dy
+	}
+	if g.panel != nil {
+		g.panel = g.newControlPanel()
+	}
+	// Recorded clicks no longer lead to this field from a fresh pond
+	g.stepCount, g.events, g.replay = 0, nil, nil
+}
+
+// stepFraction adds delta to a share in [0, 1] such as a reflectivity, clamped
+// and rounded to a multiple of delta so repeated steps land exactly on the ends.
+func stepFraction(r, delta float64) float64 {
+	step := math.Abs(delta)
+	return math.Max(0, math.Min(1, math.Round((r+delta)/step)*step))
+}
+
+// stepBackOnce runs the physics one fixed step of dt seconds back in time,
+// stopping the reversal when there is no more history to go back through.
+func (g *Game) stepBackOnce(dt float64) {
+	if !g.reversal.stepBack(g.waveGrid.Grid) {
+		g.reversal.reversing = false
+		return
+	}
+	g.simTime -= dt
+}
+
+// stepOnce advances the physics by one fixed step of dt seconds.
+func (g *Game) stepOnce(dt float64) {
+	g.injectReplay()
+	if g.rain != nil {
+		g.rain.step(g.waveGrid)
+	}
+	g.waveGrid.Step()
+	if !g.waveGrid.Finite() {
+		// Unstable settings grow the field until it overflows, and from there
+		// NaN spreads through the whole pond. Start over from still water
+		// instead of drawing a black screen
+		log.Printf("field went unstable at step %d, resetting it to still water", g.stepCount)
+		g.waveGrid.ClearField()
+		g.reversal.reset(g.waveGrid.Grid)
+		g.blowups++
+	}
+	g.reversal.recorded(g.waveGrid.Grid)
+	g.simTime += dt
+	g.stepCount++
+
+	if g.morphing != 0 {
+		wg := g.waveGrid
+		t := wg.Morph() + g.morphing*dt/morphDuration
+		if t <= 0 || t >= 1 {
+			g.morphing = -g.morphing // bounce back
+		}
+		wg.SetMorph(t)
+	}
+
+	wg := g.waveGrid
+	g.heightProbe.record(wg.HeightAt(int(g.heightProbe.x/wg.CellSize()), int(g.heightProbe.y/wg.CellSize())))
+}
+
+func (g *Game) saveRecording() {
+	name, err := g.recorder.stop()
+	if err != nil {
+		log.Printf("saving recording: %v", err)
+	} else {
+		log.Printf("recording saved to %s", name)
 	}
-	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	g.waveGrid.draw(screen)
+	g.waveGrid.draw(screen, &g.renderer, g.normValue, &g.view)
+	if g.showFlux {
+		g.drawFlux(screen)
+	}
+	g.drawLabels(screen)
+	if g.probe != nil {
+		x, y := g.gridToScreen(g.probe.x, g.probe.y)
+		vector.StrokeCircle(screen, float32(x), float32(y), 5, 1.5, color.White, false)
+		ebitenutil.DebugPrintAt(screen, "Right click to move the audio probe", 0, 112)
+	}
+
+	px, py := g.gridToScreen(g.heightProbe.x, g.heightProbe.y)
+	g.heightProbe.draw(screen, px, py, 0)
+	if g.histogram != nil {
+		g.histogram.draw(screen, "Heights", 1)
+	}
+
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("t = %.1fs", g.simTime), g.cfg.ScreenWidth-80, 0)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Max |h| %.2f | %d active cells | Energy %.1f", g.stats.MaxHeight, g.stats.ActiveCells, g.stats.Energy), g.cfg.ScreenWidth-320, 16)
+	switch wg := g.waveGrid; {
+	case wg.ConserveEnergy:
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Energy held constant, drift %+.1e | F11 to stop", wg.EnergyDrift()), g.cfg.ScreenWidth-320, 32)
+	case wg.TrackEnergy:
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Energy drift %+.1e | F11 to hold it", wg.EnergyDrift()), g.cfg.ScreenWidth-320, 32)
+	default:
+		ebitenutil.DebugPrintAt(screen, "F11 to track the energy drift", g.cfg.ScreenWidth-320, 32)
+	}
+
+	lock := "auto"
+	if g.normLocked {
+		lock = "locked"
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Norm: %.2f (%s) | Press L to lock | M to dump mask | S to save PNG | J to export JSON (Shift+J for a CSV profile) | G to record GIF | F5/F9 to save/load | Brush: %.0f (Ctrl+wheel) | Cells: %dpx (+/-) | Y for a histogram | A for energy flux arrows | Pond: %s (Shift+1-5)", g.normValue, lock, g.brushRadius, g.cfg.GridSize, g.cfg.Shape), 0, 48)
+	if g.labelMode {
+		ebitenutil.DebugPrintAt(screen, "Label mode: click to place/edit (on a pin to follow it), Enter to finish, right click or Delete to remove, T to exit", 0, 64)
+	}
+	if p := g.waveGrid.Paddle; p != nil {
+		g.drawPaddle(screen, p)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Paddle: %.0f Hz (Q/E) | W to remove", p.Frequency), 0, 128)
+	} else {
+		ebitenutil.DebugPrintAt(screen, "W for a plane-wave paddle at the cursor column, Shift+W for the row", 0, 128)
+	}
+	for _, p := range g.waveGrid.Pins {
+		x, y := g.gridToScreen(p.X, p.Y)
+		vector.StrokeCircle(screen, float32(x), float32(y), 4, 1.5, color.RGBA{255, 200, 80, 255}, true)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Pins: %d/%d at %.0f Hz | Shift+click to add or remove one | Hold D or U and press for a finger in the water", len(g.waveGrid.Pins), maxPins, pinFrequency), 0, 160)
+	if g.recorder.recording {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("REC %d frames | G to stop", len(g.recorder.frames)), 0, 96)
+	}
+	if g.paused {
+		ebitenutil.DebugPrintAt(screen, "PAUSED | Space to resume, . to step", 0, 80)
+	}
+	if len(g.replay) > 0 {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("REPLAY %d events left", len(g.replay)), 0, 144)
+	} else {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d clicks recorded | F6 to save them, F7 to replay the saved ones", len(g.events)), 0, 144)
+	}
+	edges := "walls"
+	if g.waveGrid.Periodic() {
+		edges = "wrapped around"
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Edges: %s | X to toggle | Laplacian: %s (H) | Speed across/down: %.2f (Shift+Left/Right)", edges, g.waveGrid.Stencil(), g.waveGrid.Anisotropy()), 0, 192)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Zoom: %.1fx (wheel) | Middle drag or Ctrl+arrows to pan | 0 to reset the view", g.view.scale), 0, 208)
+	if g.reversal.reversing {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("REVERSING %.1fs left | Backspace to stop", g.reversal.seconds(g.timestep.dt)), 0, 224)
+	} else {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Backspace to run the waves back up to %.1fs", g.reversal.seconds(g.timestep.dt)), 0, 224)
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("F8 to seed the heights from %s", g.cfg.SeedImage), 0, 240)
+	if g.blowups > 0 {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("UNSTABLE: the field blew up and was reset %d times, lower the speed", g.blowups), 0, 176)
+	}
+	if g.tour != nil {
+		g.drawTour(screen)
+	} else if g.tir != nil {
+		g.drawTIR(screen)
+	} else {
+		ebitenutil.DebugPrintAt(screen, "F10 for a guided tour | F4 for total internal reflection | Tab for sliders", 0, 256)
+	}
+	if g.panel != nil {
+		g.panel.draw(screen)
+	}
+}
+
+// drawPaddle marks the driven line across the whole screen.
+func (g *Game) drawPaddle(screen *ebiten.Image, p *wavegrid.Paddle) {
+	pos := (float64(p.Line) + 0.5) * g.waveGrid.CellSize()
+	w, h := float32(g.cfg.ScreenWidth), float32(g.cfg.ScreenHeight)
+	c := color.RGBA{255, 200, 80, 160}
+	if p.Vertical {
+		x, _ := g.gridToScreen(pos, 0)
+		vector.StrokeLine(screen, float32(x), 0, float32(x), h, 1, c, false)
+	} else {
+		_, y := g.gridToScreen(0, pos)
+		vector.StrokeLine(screen, 0, float32(y), w, float32(y), 1, c, false)
+	}
 }
 
+// Layout makes the screen as big as the window, and Update rebuilds the grid
+// to match.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
+	if outsideWidth <= 0 || outsideHeight <= 0 {
+		// Minimized
+		return g.cfg.ScreenWidth, g.cfg.ScreenHeight
+	}
+	g.windowWidth, g.windowHeight = outsideWidth, outsideHeight
+	return outsideWidth, outsideHeight
+}
+
+// usageError reports a bad flag value with the usage message and exits with
+// status 2, as flag.Parse does for flags it can't parse.
+func usageError(format string, args ...any) {
+	fmt.Fprintf(flag.CommandLine.Output(), format+"\n", args...)
+	flag.Usage()
+	os.Exit(2)
 }
 
 func main() {
-	ebiten.SetWindowSize(screenWidth, screenHeight)
+	defaults := DefaultConfig()
+	mode := flag.String("mode", "grid", "simulator to run: grid, analytic, particle, spectral, headless, or sweep to find the resonances of a circular pond")
+	steps := flag.Int("steps", 1000, "number of steps to simulate in headless mode")
+	shapeName := flag.String("shape", "circle", "pond shape for grid mode: circle, rect, triangle, star, hexagon, ponds, box or open")
+	withAudio := flag.Bool("audio", false, "play the wave height at a probe point as sound (grid mode)")
+	rain := flag.Bool("rain", false, "drop random raindrops into the pond (grid mode)")
+	seed := flag.Uint64("seed", 1, "random seed for -rain, the same seed gives the same drops")
+	collisions := flag.Bool("collisions", false, "let particles collide elastically with each other (particle mode)")
+	shapeFile := flag.String("shapefile", "", "load the pond outline from a file of x,y lines or an SVG polyline")
+	speed := flag.Float64("speed", defaults.WaveSpeed, "wave speed in grid cells per step")
+	damping := flag.Float64("damping", defaults.Damping, "velocity kept each step, 1 for none lost")
+	width := flag.Int("width", defaults.ScreenWidth, "window width in pixels")
+	height := flag.Int("height", defaults.ScreenHeight, "window height in pixels")
+	periodic := flag.Bool("periodic", false, "wrap the grid edges around so waves leaving one side come back on the other, with -shape open (grid mode)")
+	anisotropy := flag.Float64("anisotropy", defaults.Anisotropy, "horizontal over vertical wave speed, for a medium like a crystal (grid mode)")
+	seedImage := flag.String("seedimage", defaults.SeedImage, "PNG whose luminance F8 turns into wave heights (grid mode)")
+	maxSources := flag.Int("maxsources", defaults.MaxSources, "sources kept in analytic mode before the oldest are dropped")
+	tour := flag.Bool("tour", false, "start with the guided tour playing, for presentations (grid mode)")
+	bounces := flag.Int("bounces", defaults.Bounces, "echoes off the boundary each pulse makes in analytic mode, 1 to "+fmt.Sprint(maxBounces))
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of the run to this file, for go tool pprof")
+	flag.Parse()
+
+	cfg := defaults
+	cfg.WaveSpeed = *speed
+	cfg.Damping = *damping
+	cfg.ScreenWidth, cfg.ScreenHeight = *width, *height
+	cfg.Shape = *shapeName
+	cfg.ShapeFile = *shapeFile
+	cfg.RainMode = *rain
+	cfg.Periodic = *periodic
+	cfg.Anisotropy = *anisotropy
+	cfg.SeedImage = *seedImage
+	cfg.MaxSources = *maxSources
+	cfg.Bounces = *bounces
+	cfg.RainSeed = *seed
+	cfg.Collisions = *collisions
+	if limit := wavegrid.Averaged.SpeedLimit(); cfg.WaveSpeed < wavegrid.MinWaveSpeed || cfg.WaveSpeed > limit {
+		usageError("-speed must be from %g to %g cells per step, where the solver is stable, got %g", wavegrid.MinWaveSpeed, limit, cfg.WaveSpeed)
+	}
+	if cfg.Damping <= 0 || cfg.Damping > 1 {
+		usageError("-damping must be above 0 and at most 1, got %g", cfg.Damping)
+	}
+	if cfg.ScreenWidth <= 0 || cfg.ScreenHeight <= 0 {
+		usageError("window size must be positive, got %dx%d", cfg.ScreenWidth, cfg.ScreenHeight)
+	}
+	if cfg.Anisotropy <= 0 {
+		usageError("-anisotropy must be positive, got %g", cfg.Anisotropy)
+	}
+	if cfg.MaxSources <= 0 {
+		usageError("-maxsources must be positive, got %d", cfg.MaxSources)
+	}
+	if cfg.Bounces < 1 || cfg.Bounces > maxBounces {
+		usageError("-bounces must be from 1 to %d, got %d", maxBounces, cfg.Bounces)
+	}
+	log.Printf("mode %s, config %+v", *mode, cfg)
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			log.Fatal(err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	if *mode == "sweep" {
+		RunSweep(cfg.stepTime())
+		return
+	}
+
+	if *mode == "headless" {
+		heights := RunHeadless(cfg, *steps, []Impulse{{0, float64(cfg.ScreenWidth) / 2, float64(cfg.ScreenHeight) / 2, defaultBrushRadius}})
+		maxH := 0.0
+		for _, row := range heights {
+			for _, h := range row {
+				maxH = math.Max(maxH, math.Abs(h))
+			}
+		}
+		fmt.Printf("%d steps, max |height| %.4f\n", *steps, maxH)
+		return
+	}
+
+	var game ebiten.Game
+	switch *mode {
+	case "grid":
+		g, err := NewGame(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *tour {
+			g.startTour()
+		}
+		if *withAudio {
+			// Start at the pond center, where a centered pulse refocuses
+			g.probe, err = NewAudioProbe(g.waveGrid.Center())
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+		game = g
+	case "analytic":
+		game = NewAnalyticGame(cfg)
+	case "particle":
+		game = NewParticleGame(cfg)
+	case "spectral":
+		g, err := NewSpectralGame(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		game = g
+	default:
+		log.Fatalf("unknown mode %q", *mode)
+	}
+
+	ebiten.SetWindowSize(cfg.ScreenWidth, cfg.ScreenHeight)
 	ebiten.SetWindowTitle("Wave Simulation - Pond")
-	if err := ebiten.RunGame(NewGame()); err != nil {
+	if err := ebiten.RunGame(game); err != nil {
 		panic(err)
 	}
 }
-
-func randRange(min, max int) int {
-	return rand.IntN(max-min) + min
-}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// newTestGame returns a grid-mode game with the default config, without
+// touching the window.
+func newTestGame(t *testing.T) *Game {
+	t.Helper()
+	g, err := NewGame(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// TestClickNearEdge clicks half a pixel inside the rim of the default round
+// pond, all the way around, the way Update handles a click. Each must snap to
+// a pond cell and make an impulse there, and the analytic view must accept the
+// same points for a source.
+func TestClickNearEdge(t *testing.T) {
+	g := newTestGame(t)
+	analytic := NewAnalyticGame(DefaultConfig())
+	wg := g.waveGrid
+	cx, cy := wg.Center()
+	r := DefaultConfig().ShapeRadius - 0.5
+	for i := range 16 {
+		angle := 2 * math.Pi * float64(i) / 16
+		x, y := cx+r*math.Cos(angle), cy+r*math.Sin(angle)
+
+		wg.ClearField()
+		sx, sy, ok := wg.SnapToMask(x, y, edgeTolerance)
+		if !ok {
+			t.Errorf("click at (%g, %g) found no pond cell", x, y)
+			continue
+		}
+		g.addWave(sx, sy)
+		if !slices.ContainsFunc(wg.Velocities(), func(v float64) bool { return v != 0 }) {
+			t.Errorf("click at (%g, %g) made no impulse", x, y)
+		}
+		if _, ok := analytic.shape.SnapInside(x, y, edgeTolerance); !ok {
+			t.Errorf("analytic view rejects a click at (%g, %g)", x, y)
+		}
+	}
+}
+
+// TestUnstableFieldReset runs a click in a small pond whose damping doubles
+// the velocities every step, so the field overflows in about a thousand
+// steps. The guard must catch it on the step it happens and leave still,
+// finite water for the run to carry on from.
+func TestUnstableFieldReset(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ScreenWidth, cfg.ScreenHeight, cfg.ShapeRadius = 200, 200, 80
+	cfg.Damping = 2
+	g, err := NewGame(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.addWave(100, 100)
+	for range 5000 {
+		if g.stepOnce(g.timestep.dt); g.blowups > 0 {
+			break
+		}
+	}
+	if g.blowups == 0 {
+		t.Fatal("the field never went unstable")
+	}
+	wg := g.waveGrid
+	if !wg.Finite() || slices.ContainsFunc(wg.Heights(), func(h float64) bool { return h != 0 }) {
+		t.Fatal("the field is not still water after the guard")
+	}
+}
+
+// TestFreshPondKeepsKeySettings changes the speed, damping and reflectivity
+// the way the arrow keys and B do, on the grid alone, and starts over with
+// Ctrl+R's freshPond. The new pond must keep all three instead of going back
+// to the startup config.
+func TestFreshPondKeepsKeySettings(t *testing.T) {
+	g := newTestGame(t)
+	wg := g.waveGrid
+	wg.SetWaveSpeed(wg.WaveSpeed() + waveSpeedStep)
+	wg.SetDamping(math.Max(minDamping, wg.Damping()-dampingStep))
+	wg.Reflectivity = 0
+	speed, damping := wg.WaveSpeed(), wg.Damping()
+	if speed == g.cfg.WaveSpeed {
+		t.Fatalf("speed %g is still the config's", speed)
+	}
+
+	if err := g.freshPond(); err != nil {
+		t.Fatal(err)
+	}
+	wg = g.waveGrid
+	if wg.WaveSpeed() != speed || wg.Damping() != damping || wg.Reflectivity != 0 {
+		t.Errorf("speed %g, damping %g and reflectivity %g after freshPond, want %g, %g and 0",
+			wg.WaveSpeed(), wg.Damping(), wg.Reflectivity, speed, damping)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	minimapSize   = 120 // pixels per side
+	minimapMargin = 10
+)
+
+// drawMinimap draws an inset in the top-right corner with the boundary and a
+// dot at each wave source, fading with the same exp(-t/300) decay as its waves.
+func (g *AnalyticGame) drawMinimap(screen *ebiten.Image) {
+	left := float32(screen.Bounds().Dx() - minimapSize - minimapMargin)
+	top := float32(minimapMargin)
+	vector.FillRect(screen, left, top, minimapSize, minimapSize, color.RGBA{0, 0, 0, 160}, false)
+
+	// Fit the boundary with a little room to spare
+	scale := minimapSize / (2 * g.shapeRadius * 1.1)
+	toMap := func(x, y float64) (float32, float32) {
+		return left + minimapSize/2 + float32((x-g.cx)*scale), top + minimapSize/2 + float32((y-g.cy)*scale)
+	}
+
+	for i := range g.shape {
+		x0, y0 := toMap(g.shape[i].X, g.shape[i].Y)
+		next := g.shape[(i+1)%len(g.shape)]
+		x1, y1 := toMap(next.X, next.Y)
+		vector.StrokeLine(screen, x0, y0, x1, y1, 1, theme.Boundary, false)
+	}
+
+	for _, source := range g.waveSources {
+		strength := 1.0
+		if !source.continuous {
+			strength = math.Exp(-float64(g.frame-source.createdAt) / 300)
+		}
+		x, y := toMap(source.x, source.y)
+		vector.DrawFilledCircle(screen, x, y, 2, color.RGBA{255, 220, 120, uint8(255 * strength)}, false)
+	}
+}
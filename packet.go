@@ -0,0 +1,88 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// A wave packet is a Gaussian band of wavelengths around its source's
+// wavelength, summed as outgoing rings that all start in phase at the source.
+// Each component crest moves at the phase velocity of its wavelength, but the
+// envelope, where the components agree, moves at the group velocity dω/dk.
+//
+// Without dispersion every component has the same speed, so ω = c·k, the
+// group velocity equals the phase velocity and the packet keeps its shape.
+// With dispersion c = c0·sqrt(λ/λ0), so ω = c0·sqrt(k0·k) and dω/dk = c/2:
+// the envelope travels at half the speed of its crests, which are seen to
+// appear at its back, run through it and vanish at its front, while the
+// packet slowly widens. The overlay prints both speeds to compare.
+const (
+	packetComponents = 25   // wavelengths summed per packet
+	packetWidth      = 25.0 // pixels, standard deviation of the envelope at launch
+
+	// packetSpread is how many standard deviations of the spectrum the
+	// components cover on each side of the center
+	packetSpread = 3.0
+)
+
+// packetWave returns the height of the packet of source at distance r from it,
+// t ticks after launch, and the envelope around that height.
+func (g *AnalyticGame) packetWave(r, t float64, source WaveSource) (height, envelope float64) {
+	k0 := 2 * math.Pi / source.wavelength
+	sigmaK := 1 / packetWidth
+	// The spacing of the components sets where the packet repeats, at 2π/dk,
+	// about 630 pixels: safely outside the pond
+	dk := 2 * packetSpread * sigmaK / (packetComponents - 1)
+
+	var re, im, total float64
+	for j := range packetComponents {
+		k := k0 + (float64(j)-(packetComponents-1)/2.0)*dk
+		w := math.Exp(-(k - k0) * (k - k0) / (2 * sigmaK * sigmaK))
+		omega := g.waveSpeed(2*math.Pi/k) * k
+		phase := k*r - omega*t + source.phase
+		re += w * math.Cos(phase)
+		im += w * math.Sin(phase)
+		total += w
+	}
+	a := source.amplitude * math.Exp(-t/300) / total
+	return a * im, a * math.Hypot(re, im)
+}
+
+// packetPeak returns the distance from source at which its envelope peaks, and
+// the inner and outer distances where it falls to half of that.
+func (g *AnalyticGame) packetPeak(source WaveSource) (peak, inner, outer float64) {
+	t := float64(g.frame - source.createdAt)
+	maxR := 2 * g.shapeRadius
+	best := 0.0
+	envelopes := make([]float64, int(maxR)+1)
+	for r := range envelopes {
+		_, envelopes[r] = g.packetWave(float64(r), t, source)
+		if envelopes[r] > best {
+			best, peak = envelopes[r], float64(r)
+		}
+	}
+	inner, outer = peak, peak
+	for inner > 0 && envelopes[int(inner)] > best/2 {
+		inner--
+	}
+	for outer < maxR && envelopes[int(outer)] > best/2 {
+		outer++
+	}
+	return peak, inner, outer
+}
+
+// drawPacketEnvelope outlines the envelope of a packet with a circle at its
+// peak and fainter ones at its half-height edges.
+func (g *AnalyticGame) drawPacketEnvelope(screen *ebiten.Image, source WaveSource) {
+	peak, inner, outer := g.packetPeak(source)
+	x, y := float32(source.x), float32(source.y)
+	vector.StrokeCircle(screen, x, y, float32(peak), 1.5, color.RGBA{255, 220, 120, 220}, false)
+	for _, r := range []float64{inner, outer} {
+		if r > 0 {
+			vector.StrokeCircle(screen, x, y, float32(r), 1, color.RGBA{255, 220, 120, 110}, false)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"game/wavegrid"
+)
+
+const (
+	particleTickTime   = 1.0 / 60 // real seconds per particle update
+	particlesPerWave   = 8
+	particleSpeed      = 2.0
+	particleMaxAge     = 0.8  // seconds
+	particleFriction   = 0.98 // velocity kept each tick
+	particleBounceLoss = 0.95 // velocity kept on each boundary bounce
+
+	particleRadius = 1.5 // pixels, particles closer than twice this collide
+
+	ringStartRadius = 5.0
+	ringMaxRadius   = 300.0
+	ringGrowth      = 2.5 // pixels per tick
+)
+
+type Particle struct {
+	pos, vel wavegrid.Vector2
+	age      float64
+	maxAge   float64
+}
+
+func (p Particle) dead() bool {
+	return p.age > p.maxAge
+}
+
+// Ring is an expanding wavefront that sheds particles as it grows.
+type Ring struct {
+	center    wavegrid.Vector2
+	radius    float64
+	maxRadius float64
+}
+
+func (r Ring) dead() bool {
+	return r.radius > r.maxRadius
+}
+
+// ParticleGame shows waves as expanding rings that shed particles, which
+// bounce off the circular boundary.
+type ParticleGame struct {
+	cfg         Config
+	center      wavegrid.Vector2
+	shapeRadius float64
+	shape       wavegrid.Shape // the boundary as a polygon, for click tests
+	particles   []Particle
+	rings       []Ring
+	hash        *SpatialHash     // particle positions, rebuilt every tick
+	touchIDs    []ebiten.TouchID // reused buffer for new touches
+	pressed     bool
+	paused      bool
+
+	timestep fixedTimestep
+}
+
+func NewParticleGame(cfg Config) *ParticleGame {
+	return &ParticleGame{
+		cfg:         cfg,
+		center:      wavegrid.Vector2{X: float64(cfg.ScreenWidth) / 2, Y: float64(cfg.ScreenHeight) / 2},
+		shapeRadius: cfg.ShapeRadius,
+		shape:       wavegrid.Circle(float64(cfg.ScreenWidth)/2, float64(cfg.ScreenHeight)/2, cfg.ShapeRadius),
+		hash:        NewSpatialHash(2 * particleRadius),
+		timestep:    fixedTimestep{dt: particleTickTime},
+	}
+}
+
+func (g *ParticleGame) Update() error {
+	updateTheme()
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		if !g.pressed {
+			mx, my := ebiten.CursorPosition()
+			if p, ok := g.shape.SnapInside(float64(mx), float64(my), edgeTolerance); ok {
+				g.rings = append(g.rings, Ring{p, ringStartRadius, ringMaxRadius})
+			}
+			g.pressed = true
+		}
+	} else {
+		g.pressed = false
+	}
+	// Each new touch starts one ring, like a fresh click
+	g.touchIDs = inpututil.AppendJustPressedTouchIDs(g.touchIDs[:0])
+	for _, id := range g.touchIDs {
+		x, y := ebiten.TouchPosition(id)
+		if p, ok := g.shape.SnapInside(float64(x), float64(y), edgeTolerance); ok {
+			g.rings = append(g.rings, Ring{p, ringStartRadius, ringMaxRadius})
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		g.particles = nil
+		g.rings = nil
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+		// Calm the water, the rings keep shedding new particles
+		g.particles = nil
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.paused = !g.paused
+	}
+
+	if !g.paused {
+		for range g.timestep.steps() {
+			g.stepOnce()
+		}
+	} else {
+		g.timestep.reset()
+		if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+			g.stepOnce()
+		}
+	}
+	return nil
+}
+
+// stepOnce grows the rings, sheds particles from them and moves the particles.
+func (g *ParticleGame) stepOnce() {
+	for i := range g.rings {
+		g.rings[i].radius += ringGrowth
+	}
+
+	for _, r := range g.rings {
+		if r.dead() {
+			continue
+		}
+		for j := 0; j < particlesPerWave; j++ {
+			angle := float64(j) * 2 * math.Pi / particlesPerWave
+			pos := r.center.Add(wavegrid.Vector2{X: math.Cos(angle), Y: math.Sin(angle)}.Scale(r.radius))
+
+			offset := pos.Sub(g.center)
+			if g.shape.Contains(pos.X, pos.Y) {
+				g.particles = append(g.particles, Particle{
+					pos:    pos,
+					vel:    offset.Normalize().Scale(particleSpeed),
+					maxAge: particleMaxAge,
+				})
+			}
+		}
+	}
+
+	for i := range g.particles {
+		p := &g.particles[i]
+		p.pos = p.pos.Add(p.vel)
+		p.age += particleTickTime
+
+		// Step back inside and bounce off the edge the particle crossed
+		if !g.shape.Contains(p.pos.X, p.pos.Y) {
+			_, n := wavegrid.ClosestEdge(g.shape, p.pos.X, p.pos.Y)
+			p.pos = p.pos.Sub(p.vel)
+			p.vel = p.vel.Reflect(n).Scale(particleBounceLoss)
+		}
+
+		p.vel = p.vel.Scale(particleFriction)
+	}
+
+	g.compact()
+
+	g.hash.Clear()
+	for i, p := range g.particles {
+		g.hash.Insert(i, p.pos.X, p.pos.Y)
+	}
+	if g.cfg.Collisions {
+		collideParticles(g.particles, g.hash, particleRadius)
+	}
+}
+
+// collideParticles bounces every pair of particles closer than 2*radius that
+// are still approaching. Particles have equal mass, so an elastic collision
+// just swaps their velocity components along the line between them, which
+// conserves momentum and energy. The hash must hold the particle indices at
+// their current positions.
+func collideParticles(particles []Particle, hash *SpatialHash, radius float64) {
+	diameter := 2 * radius
+	for i := range particles {
+		a := &particles[i]
+		for _, j := range hash.Near(a.pos.X, a.pos.Y, diameter) {
+			if j <= i {
+				continue // each pair once
+			}
+			b := &particles[j]
+			offset := a.pos.Sub(b.pos)
+			dist := offset.Length()
+			if dist >= diameter || dist == 0 {
+				continue
+			}
+			n := offset.Scale(1 / dist)
+			closing := a.vel.Sub(b.vel).Dot(n)
+			if closing >= 0 {
+				continue // already separating
+			}
+			a.vel = a.vel.Sub(n.Scale(closing))
+			b.vel = b.vel.Add(n.Scale(closing))
+		}
+	}
+}
+
+// compact drops expired rings and particles in a single in-place pass each,
+// keeping the survivors in order.
+func (g *ParticleGame) compact() {
+	rings := g.rings[:0]
+	for _, r := range g.rings {
+		if !r.dead() {
+			rings = append(rings, r)
+		}
+	}
+	g.rings = rings
+
+	particles := g.particles[:0]
+	for _, p := range g.particles {
+		if !p.dead() {
+			particles = append(particles, p)
+		}
+	}
+	g.particles = particles
+}
+
+func (g *ParticleGame) Draw(screen *ebiten.Image) {
+	screen.Fill(theme.Background)
+
+	vector.StrokeCircle(screen, float32(g.center.X), float32(g.center.Y), float32(g.shapeRadius), 2, theme.Boundary, false)
+
+	for _, r := range g.rings {
+		alpha := uint8(200 * (1 - r.radius/r.maxRadius))
+		vector.StrokeCircle(screen, float32(r.center.X), float32(r.center.Y), float32(r.radius), 1.5, color.RGBA{100, 200, 255, alpha}, false)
+	}
+
+	for _, p := range g.particles {
+		alpha := uint8(255 * (1 - p.age/p.maxAge))
+		vector.DrawFilledCircle(screen, float32(p.pos.X), float32(p.pos.Y), particleRadius, color.RGBA{150, 220, 255, alpha}, false)
+	}
+
+	ebitenutil.DebugPrint(screen, "Click inside the circle to create waves\nR to reset | X to clear particles but keep the waves | Space to pause, . to step | Shift+K for the theme")
+	if g.paused {
+		ebitenutil.DebugPrintAt(screen, "PAUSED", 0, 32)
+	}
+}
+
+func (g *ParticleGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return g.cfg.ScreenWidth, g.cfg.ScreenHeight
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"game/wavegrid"
+)
+
+// TestCompact expires several particles and rings in the same tick, some
+// next to each other and one at each end. All of them must go, with the
+// survivors kept in order.
+func TestCompact(t *testing.T) {
+	g := NewParticleGame(DefaultConfig())
+	for i, dead := range []bool{true, false, true, true, false, true, false, true} {
+		age, radius := 0.0, ringStartRadius
+		if dead {
+			age, radius = 2*particleMaxAge, 2*ringMaxRadius
+		}
+		g.particles = append(g.particles, Particle{pos: wavegrid.Vector2{X: float64(i)}, age: age, maxAge: particleMaxAge})
+		g.rings = append(g.rings, Ring{center: wavegrid.Vector2{X: float64(i)}, radius: radius, maxRadius: ringMaxRadius})
+	}
+
+	g.compact()
+	want := []float64{1, 4, 6}
+	if len(g.particles) != len(want) || len(g.rings) != len(want) {
+		t.Fatalf("%d particles and %d rings left, want %d of each", len(g.particles), len(g.rings), len(want))
+	}
+	for i, x := range want {
+		if g.particles[i].pos.X != x || g.rings[i].center.X != x {
+			t.Errorf("survivor %d is particle %g and ring %g, want %g", i, g.particles[i].pos.X, g.rings[i].center.X, x)
+		}
+	}
+}
+
+// TestHeadOnCollision runs two particles of equal mass into each other along
+// a line. The collision must conserve momentum and energy, which for a
+// head-on one means they swap velocities.
+func TestHeadOnCollision(t *testing.T) {
+	particles := []Particle{
+		{pos: wavegrid.Vector2{X: 100, Y: 50}, vel: wavegrid.Vector2{X: 1.5}},
+		{pos: wavegrid.Vector2{X: 102, Y: 50}, vel: wavegrid.Vector2{X: -0.5}},
+	}
+	momentum := particles[0].vel.Add(particles[1].vel)
+	energy := particles[0].vel.Dot(particles[0].vel) + particles[1].vel.Dot(particles[1].vel)
+
+	hash := NewSpatialHash(2 * particleRadius)
+	for i, p := range particles {
+		hash.Insert(i, p.pos.X, p.pos.Y)
+	}
+	collideParticles(particles, hash, particleRadius)
+
+	a, b := particles[0].vel, particles[1].vel
+	if got := a.Add(b); got != momentum {
+		t.Errorf("momentum %v after the collision, want %v", got, momentum)
+	}
+	if got := a.Dot(a) + b.Dot(b); got != energy {
+		t.Errorf("energy %g after the collision, want %g", got, energy)
+	}
+	if a != (wavegrid.Vector2{X: -0.5}) || b != (wavegrid.Vector2{X: 1.5}) {
+		t.Errorf("velocities %v and %v, want them swapped", a, b)
+	}
+}
+
+// TestReflectOffDiagonalWall sends a particle across the 45° wall of a
+// triangular pond. It must come back along the wall's true normal, turning a
+// rightward run into an upward one, not off the radial direction a circular
+// pond would give.
+func TestReflectOffDiagonalWall(t *testing.T) {
+	g := NewParticleGame(DefaultConfig())
+	g.shape = wavegrid.Shape{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 0, Y: 100}}
+	g.particles = []Particle{{pos: wavegrid.Vector2{X: 49, Y: 49}, vel: wavegrid.Vector2{X: 3}, maxAge: particleMaxAge}}
+	g.stepOnce()
+
+	p := g.particles[0]
+	if p.pos != (wavegrid.Vector2{X: 49, Y: 49}) {
+		t.Errorf("particle at %v after bouncing, want it stepped back to (49, 49)", p.pos)
+	}
+	want := wavegrid.Vector2{Y: -3 * particleBounceLoss * particleFriction}
+	if math.Abs(p.vel.X-want.X) > 1e-12 || math.Abs(p.vel.Y-want.Y) > 1e-12 {
+		t.Errorf("velocity %v after bouncing, want %v", p.vel, want)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// HeightProbe records the wave height at one point over time, so a glance at
+// its graph shows whether the water there really oscillates sinusoidally. It
+// follows the cursor until frozen.
+type HeightProbe struct {
+	x, y    float64 // in the coordinates of the simulator that owns it
+	frozen  bool
+	history *ringBuffer
+}
+
+func NewHeightProbe() *HeightProbe {
+	return &HeightProbe{history: newRingBuffer(graphSamples)}
+}
+
+// follow moves the probe to (x, y) unless it is frozen.
+func (p *HeightProbe) follow(x, y float64) {
+	if !p.frozen {
+		p.x, p.y = x, y
+	}
+}
+
+func (p *HeightProbe) record(h float64) {
+	p.history.push(h)
+}
+
+// draw plots the history in graph slot, marking the probed point at screen
+// position (sx, sy) once it no longer sits under the cursor.
+func (p *HeightProbe) draw(screen *ebiten.Image, sx, sy float64, slot int) {
+	label := "Probe (F to freeze)"
+	if p.frozen {
+		label = "Probe (frozen, F)"
+		vector.StrokeLine(screen, float32(sx)-4, float32(sy), float32(sx)+4, float32(sy), 1, color.White, false)
+		vector.StrokeLine(screen, float32(sx), float32(sy)-4, float32(sx), float32(sy)+4, 1, color.White, false)
+	}
+	drawGraph(screen, label, p.history, slot)
+}
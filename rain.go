@@ -0,0 +1,45 @@
+package main
+
+import "math/rand/v2"
+
+const (
+	rainDropsPerSecond = 4.0 // of simulated time
+	rainDropRadius     = 4.0 // cells, smaller than a click
+	rainPlacementTries = 100 // random points tried before giving up on a drop
+)
+
+// Rain drops impulses at random points inside the mask at a steady rate. Drops
+// are counted in solver steps and drawn from a seeded generator, so the same
+// seed rains on the same cells in the same order on every run.
+type Rain struct {
+	rng      *rand.Rand
+	interval int // solver steps between drops
+	counter  int
+}
+
+func NewRain(seed uint64, stepTime float64) *Rain {
+	return &Rain{
+		rng:      rand.New(rand.NewPCG(seed, seed)),
+		interval: max(1, int(1/(rainDropsPerSecond*stepTime))),
+	}
+}
+
+// step is called once per solver step and drops a raindrop every interval steps.
+func (r *Rain) step(wg *WaveGrid) {
+	width, height := wg.Size()
+	r.counter++
+	if r.counter < r.interval {
+		return
+	}
+	r.counter = 0
+
+	for range rainPlacementTries {
+		x := r.rng.IntN(width)
+		y := r.rng.IntN(height)
+		if wg.InMask(x, y) {
+			// addWave takes pixels, aim at the cell center
+			wg.AddWave((float64(x)+0.5)*wg.CellSize(), (float64(y)+0.5)*wg.CellSize(), rainDropRadius)
+			return
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestRainSeed rains a few drops on three still ponds, two with the same
+// seed, without stepping them so the drops stay where they fell. Those two
+// must end up with identical velocities, and the third with others.
+func TestRainSeed(t *testing.T) {
+	field := func(seed uint64) []float64 {
+		g := newTestGame(t)
+		rain := NewRain(seed, g.cfg.stepTime())
+		for range 5 * rain.interval {
+			rain.step(g.waveGrid)
+		}
+		return slices.Clone(g.waveGrid.Velocities())
+	}
+	a, b, other := field(7), field(7), field(8)
+	if !slices.ContainsFunc(a, func(h float64) bool { return h != 0 }) {
+		t.Fatal("no drop fell")
+	}
+	if !slices.Equal(a, b) {
+		t.Error("the same seed rained differently")
+	}
+	if slices.Equal(a, other) {
+		t.Error("different seeds rained the same")
+	}
+}
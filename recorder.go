@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	gifFrameSkip    = 4   // record every 4th frame to keep the file size reasonable
+	maxGIFFrames    = 300 // recording stops on its own past this many frames
+	gifCentiseconds = 100 // GIF delays are expressed in 100ths of a second
+)
+
+// Recorder accumulates rendered frames into an animated GIF.
+type Recorder struct {
+	frames    []*image.Paletted
+	frameSkip int
+	counter   int
+	recording bool
+}
+
+func NewRecorder(frameSkip int) *Recorder {
+	return &Recorder{frameSkip: frameSkip}
+}
+
+func (r *Recorder) start() {
+	r.frames = nil
+	r.counter = 0
+	r.recording = true
+}
+
+// capture renders and keeps a frame every frameSkip calls. render is only
+// invoked for kept frames since rendering the whole grid is expensive.
+func (r *Recorder) capture(render func() *image.RGBA) {
+	if !r.recording {
+		return
+	}
+	r.counter++
+	if (r.counter-1)%r.frameSkip != 0 {
+		return
+	}
+
+	img := render()
+	paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, image.Point{})
+	r.frames = append(r.frames, paletted)
+}
+
+// full reports whether the recording reached maxGIFFrames.
+func (r *Recorder) full() bool {
+	return len(r.frames) >= maxGIFFrames
+}
+
+// stop ends the recording and writes it to a timestamped looping GIF,
+// returning the file name.
+func (r *Recorder) stop() (string, error) {
+	r.recording = false
+	frames := r.frames
+	r.frames = nil
+	if len(frames) == 0 {
+		return "", fmt.Errorf("no frames recorded")
+	}
+
+	delay := r.frameSkip * gifCentiseconds / ebiten.TPS()
+	anim := &gif.GIF{LoopCount: 0}
+	for _, f := range frames {
+		anim.Image = append(anim.Image, f)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	name := fmt.Sprintf("wave_%s.gif", time.Now().Format("20060102_150405.000"))
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return "", err
+	}
+	return name, f.Close()
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	"game/wavegrid"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+var (
+	obstacleColor  = color.RGBA{140, 120, 90, 255}
+	interfaceColor = color.RGBA{200, 230, 255, 255}
+)
+
+// WaveGrid is the solver grid of grid mode along with how it is drawn.
+type WaveGrid struct {
+	*wavegrid.Grid
+
+	renderMode  RenderMode
+	displayMode DisplayMode // what FlatColor maps to colors
+	colorMap    ColorMap    // used by the FlatColor render mode
+
+	contourLevels []float64 // heights traced by the contour overlay
+	showContours  bool
+
+	// snapshot is a frozen copy of the heights drawn faded under the live
+	// field, with the normalization it was shown at; nil when off
+	snapshot     [][]float64
+	snapshotNorm float64
+}
+
+// NewWaveGrid creates the grid of cfg with the given pond outlines, see
+// wavegrid.New.
+func NewWaveGrid(cfg Config, shapes ...wavegrid.Shape) *WaveGrid {
+	return &WaveGrid{
+		Grid:          wavegrid.New(cfg.gridParams(), shapes...),
+		colorMap:      colorMaps[0],
+		contourLevels: []float64{-10, 0, 10},
+	}
+}
+
+// takeSnapshot freezes the current heights, shown at normValue, as the
+// backdrop of the live field.
+func (wg *WaveGrid) takeSnapshot(normValue float64) {
+	width, height := wg.Size()
+	wg.snapshot = make([][]float64, height)
+	for y := range wg.snapshot {
+		wg.snapshot[y] = slices.Clone(wg.Heights()[y*width : (y+1)*width])
+	}
+	wg.snapshotNorm = normValue
+}
+
+// RenderMode selects how heights are turned into colors.
+type RenderMode int
+
+const (
+	FlatColor RenderMode = iota // crest/trough color map
+	Shaded                      // diffuse lighting of the surface, like a 3D view
+)
+
+func (m RenderMode) String() string {
+	if m == Shaded {
+		return "shaded"
+	}
+	return "flat"
+}
+
+// DisplayMode selects the quantity the FlatColor render mode shows.
+type DisplayMode int
+
+const (
+	Height    DisplayMode = iota // signed height, crests and troughs in the two halves of the color map
+	Intensity                    // height squared, in the positive half, like the fringes of a diffraction pattern
+)
+
+func (m DisplayMode) String() string {
+	if m == Intensity {
+		return "intensity"
+	}
+	return "height"
+}
+
+// lightDir is the unit direction towards the light used by Shaded mode,
+// coming from the upper left and above the surface.
+var lightDir = func() [3]float64 {
+	l := math.Sqrt(0.5*0.5 + 0.5*0.5 + 1)
+	return [3]float64{-0.5 / l, -0.5 / l, 1 / l}
+}()
+
+// surfaceRelief exaggerates the height gradient so ripples catch the light.
+const surfaceRelief = 4.0
+
+// snapshotAlpha is how strongly a frozen snapshot shows through calm water.
+const snapshotAlpha = 0.45
+
+// openObstacleAlpha is how strongly a wall toggled off shows through the water.
+const openObstacleAlpha = 0.25
+
+// heightColor maps a height to its display color with cm, with normValue
+// mapped to the ends of the map.
+func heightColor(cm ColorMap, h, normValue float64) color.RGBA {
+	return cm.At(h / normValue)
+}
+
+// cellColor is the display color of one cell, including the shading of slow
+// regions. Cells the edge passes through are blended with the background by
+// their coverage.
+func (wg *WaveGrid) cellColor(x, y int, normValue float64) color.RGBA {
+	if wg.Obstacle(wg.Index(x, y)) {
+		return obstacleColor
+	}
+	coverage := wg.Coverage(wg.Index(x, y))
+	if coverage == 0 {
+		return theme.Background
+	}
+	var c color.RGBA
+	switch {
+	case wg.renderMode == Shaded:
+		c = wg.shadedColor(x, y, normValue)
+	case wg.displayMode == Intensity:
+		// Scaled so the highest crest or deepest trough gets the end color
+		h := wg.HeightAt(x, y)
+		c = heightColor(wg.colorMap, h*h/normValue, normValue)
+	default:
+		c = heightColor(wg.colorMap, wg.HeightAt(x, y), normValue)
+	}
+	if wg.snapshot != nil {
+		// The snapshot shows faded where the live water is calm, and the
+		// live waves cover it as they grow
+		calm := heightColor(wg.colorMap, 0, 1)
+		backdrop := lerpColor(calm, heightColor(wg.colorMap, wg.snapshot[y][x], wg.snapshotNorm), snapshotAlpha)
+		c = lerpColor(backdrop, c, math.Min(1, 3*math.Abs(wg.HeightAt(x, y))/normValue))
+	}
+	if wg.Speed(x, y) < wg.WaveSpeed() {
+		// Shade slow regions so the interface is visible
+		c.R, c.G, c.B = uint8(int(c.R)*3/4), uint8(int(c.G)*3/4), uint8(int(c.B)*3/4)
+	}
+	if wg.Interface(wg.Index(x, y)) {
+		// Tint interfaces more the more they reflect
+		c = lerpColor(c, interfaceColor, 0.2+0.5*(1-wg.Transmission))
+	}
+	if wg.ObstacleID(wg.Index(x, y)) > 0 {
+		// Walls toggled off stay faintly visible so they can be found again
+		c = lerpColor(c, obstacleColor, openObstacleAlpha)
+	}
+	if coverage < 1 {
+		c = lerpColor(theme.Background, c, coverage)
+	}
+	return c
+}
+
+// lerpColor mixes a and b, giving b the weight t in [0, 1].
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// shadedColor lights the surface with a fixed directional light, using the
+// normal from central differences of the heights.
+func (wg *WaveGrid) shadedColor(x, y int, normValue float64) color.RGBA {
+	scale := surfaceRelief / (2 * normValue)
+	dhdx := (wg.HeightAt(x+1, y) - wg.HeightAt(x-1, y)) * scale
+	dhdy := (wg.HeightAt(x, y+1) - wg.HeightAt(x, y-1)) * scale
+
+	// Normal of the surface z = h(x, y) is (-dh/dx, -dh/dy, 1), normalized
+	length := math.Sqrt(dhdx*dhdx + dhdy*dhdy + 1)
+	diffuse := (-dhdx*lightDir[0] - dhdy*lightDir[1] + lightDir[2]) / length
+	diffuse = math.Max(0, diffuse)
+
+	// Water tint scaled by brightness, with a little ambient light
+	brightness := 0.15 + 0.85*diffuse
+	return color.RGBA{uint8(60 * brightness), uint8(140 * brightness), uint8(230 * brightness), 255}
+}
+
+// renderPixels writes the colors of all cells into pix as RGBA bytes, one
+// pixel per cell, row by row.
+func (wg *WaveGrid) renderPixels(pix []byte, normValue float64) {
+	width, height := wg.Size()
+	i := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := wg.cellColor(x, y, normValue)
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = c.R, c.G, c.B, c.A
+			i += 4
+		}
+	}
+}
+
+// fieldRenderer holds the grid-sized image the height field is written into,
+// so drawing costs one WritePixels and one DrawImage instead of a rectangle per cell.
+type fieldRenderer struct {
+	image  *ebiten.Image
+	pixels []byte
+}
+
+func (wg *WaveGrid) draw(screen *ebiten.Image, r *fieldRenderer, normValue float64, v *view) {
+	screen.Fill(theme.Background)
+
+	width, height := wg.Size()
+	if r.image == nil || r.image.Bounds().Dx() != width || r.image.Bounds().Dy() != height {
+		r.image = ebiten.NewImage(width, height)
+		r.pixels = make([]byte, 4*width*height)
+	}
+	wg.renderPixels(r.pixels, normValue)
+	r.image.WritePixels(r.pixels)
+
+	// Draw wave grid
+	zoomScale := v.scale
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(wg.CellSize()*zoomScale, wg.CellSize()*zoomScale)
+	op.GeoM.Translate(v.offsetX, v.offsetY)
+	screen.DrawImage(r.image, op)
+
+	// Draw shape boundaries through the same transform
+	ox, oy := float32(v.offsetX), float32(v.offsetY)
+	for _, shape := range wg.Outlines() {
+		if len(shape) < 2 {
+			continue
+		}
+		for i := 0; i < len(shape)-1; i++ {
+			p1 := shape[i]
+			p2 := shape[i+1]
+			vector.StrokeLine(screen, ox+float32(p1.X*zoomScale), oy+float32(p1.Y*zoomScale), ox+float32(p2.X*zoomScale), oy+float32(p2.Y*zoomScale), 2, theme.Boundary, false)
+		}
+		// Close the shape
+		p1 := shape[len(shape)-1]
+		p2 := shape[0]
+		vector.StrokeLine(screen, ox+float32(p1.X*zoomScale), oy+float32(p1.Y*zoomScale), ox+float32(p2.X*zoomScale), oy+float32(p2.Y*zoomScale), 2, theme.Boundary, false)
+	}
+
+	if wg.showContours {
+		wg.drawContours(screen, v.offsetX, v.offsetY, zoomScale)
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %.2f\nClick to create waves | Press R to calm the water, Shift+R to also clear walls and slow regions, Ctrl+R to start over | Space to pause | P to paint a slow region | Hold O and drag to build walls, Alt+click to open/close one | Hold I and drag for a glass plate (Shift+I erases) | N to morph circle/square | Z to freeze a backdrop\nSpeed: %.2f (Up/Down) | Damping: %.3f (Left/Right) | Reflectivity: %.1f (B, PgUp/PgDn) | Transmission: %.2f ([/]) | View: %s (V), %s (Shift+V) | C for contours | Colors: %s (K) | Theme: %s (Shift+K) | Impulse: %s (1-4)", ebiten.CurrentTPS(), wg.WaveSpeed(), wg.Damping(), wg.Reflectivity, wg.Transmission, wg.renderMode, wg.displayMode, wg.colorMap, theme.name, wg.Impulse))
+}
+
+// drawContours overlays the contour lines of every level, with the zero
+// crossings (the nodes of standing waves) drawn brightest.
+func (wg *WaveGrid) drawContours(screen *ebiten.Image, offsetX, offsetY, zoomScale float64) {
+	scale := wg.CellSize() * zoomScale
+	for _, level := range wg.contourLevels {
+		c := color.RGBA{180, 180, 180, 160}
+		if level == 0 {
+			c = color.RGBA{255, 255, 255, 255}
+		}
+		for _, s := range wg.contourSegments(level) {
+			vector.StrokeLine(screen,
+				float32(offsetX+s.x1*scale), float32(offsetY+s.y1*scale),
+				float32(offsetX+s.x2*scale), float32(offsetY+s.y2*scale),
+				1, c, true)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// eventsFile is where F6 saves the recorded clicks and F7 replays them from.
+const eventsFile = "events.txt"
+
+// WriteEvents writes events one per line as "step x y radius", a format small
+// enough to edit by hand to script a demo.
+func WriteEvents(w io.Writer, events []Impulse) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# step x y radius")
+	for _, e := range events {
+		fmt.Fprintf(bw, "%d %g %g %g\n", e.step, e.x, e.y, e.radius)
+	}
+	return bw.Flush()
+}
+
+// ReadEvents reads events written by WriteEvents. Blank lines and lines
+// starting with # are skipped, the steps must not go backwards and the radii
+// must be positive.
+func ReadEvents(r io.Reader) ([]Impulse, error) {
+	var events []Impulse
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var e Impulse
+		if _, err := fmt.Sscan(text, &e.step, &e.x, &e.y, &e.radius); err != nil {
+			return nil, fmt.Errorf("line %d: expected \"step x y radius\", got %q", line, text)
+		}
+		if n := len(events); e.step < 0 || n > 0 && e.step < events[n-1].step {
+			return nil, fmt.Errorf("line %d: step %d is out of order", line, e.step)
+		}
+		if !(e.radius > 0) {
+			return nil, fmt.Errorf("line %d: radius %g is not positive", line, e.radius)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// addWave disturbs the grid at a grid position and records it, so the run
+// can be replayed later.
+func (g *Game) addWave(x, y float64) {
+	g.waveGrid.AddWave(x, y, g.brushRadius)
+	g.events = append(g.events, Impulse{g.stepCount, x, y, g.brushRadius})
+}
+
+// injectReplay applies the replayed events due at the current step. It runs
+// before anything else touches the grid in the step, as a click would.
+func (g *Game) injectReplay() {
+	for len(g.replay) > 0 && g.replay[0].step <= g.stepCount {
+		e := g.replay[0]
+		g.waveGrid.AddWave(e.x, e.y, e.radius)
+		g.events = append(g.events, e)
+		g.replay = g.replay[1:]
+	}
+}
+
+func (g *Game) saveEvents() error {
+	f, err := os.Create(eventsFile)
+	if err != nil {
+		return err
+	}
+	if err := WriteEvents(f, g.events); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// startReplay loads the saved events and restarts from a fresh pond, so they
+// land at the same steps as when they were recorded. The solver steps follow
+// the wall clock, so the replay also plays at the recorded pace.
+func (g *Game) startReplay() error {
+	f, err := os.Open(eventsFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	events, err := ReadEvents(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", eventsFile, err)
+	}
+	if err := g.freshPond(); err != nil {
+		return err
+	}
+	g.replay = events
+	return nil
+}
+
+// freshPond replaces the grid with a new one built from the config: still
+// water of one speed, without walls, slow regions, glass plates, pins or a
+// paddle. The speed, damping, reflectivity and transmission in use are kept,
+// since the keys change them on the grid alone, and so are the brush profile
+// and display settings. The step count, the recorded events and the rain
+// start over with it.
+func (g *Game) freshPond() error {
+	shapes, err := configShapes(g.cfg)
+	if err != nil {
+		return err
+	}
+
+	old := g.waveGrid
+	wg := NewWaveGrid(g.cfg, shapes...)
+	wg.renderMode, wg.showContours, wg.contourLevels = old.renderMode, old.showContours, old.contourLevels
+	wg.colorMap, wg.displayMode, wg.Impulse = old.colorMap, old.displayMode, old.Impulse
+	wg.SetWaveSpeed(old.WaveSpeed())
+	wg.SetDamping(old.Damping())
+	wg.Reflectivity, wg.Transmission = old.Reflectivity, old.Transmission
+	g.waveGrid = wg
+	if g.rain != nil {
+		g.rain = NewRain(g.cfg.RainSeed, g.cfg.stepTime())
+	}
+	g.morphing = 0
+	g.simTime = 0
+	g.stepCount = 0
+	g.events, g.replay = nil, nil
+	g.timestep.reset()
+	g.reversal.reset(wg.Grid)
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"game/wavegrid"
+)
+
+// TestFreshPond fills the pond with waves, a wall, a slow region and a pin,
+// and starts over. None of them may be left.
+func TestFreshPond(t *testing.T) {
+	g := newTestGame(t)
+	wg := g.waveGrid
+	cx, cy := wg.Center()
+	wg.AddWave(cx, cy, defaultBrushRadius)
+	wg.SetObstacle(int(cx)-50, int(cy), obstacleBrushRadius)
+	wg.PaintSpeedCircle(cx+50, cy, slowRegionRadius, slowRegionFactor)
+	wg.Pins = append(wg.Pins, wavegrid.NewContinuousSource(cx, cy+50, pinRadius, pinFrequency, pinAmplitude, g.timestep.dt))
+	g.stepCount = 10
+
+	if err := g.freshPond(); err != nil {
+		t.Fatal(err)
+	}
+	wg = g.waveGrid
+	if slices.ContainsFunc(wg.Heights(), func(h float64) bool { return h != 0 }) ||
+		slices.ContainsFunc(wg.Velocities(), func(v float64) bool { return v != 0 }) {
+		t.Error("the water is still moving")
+	}
+	if wg.Obstacle(wg.Index(int(cx)-50, int(cy))) {
+		t.Error("the wall is still there")
+	}
+	if s := wg.Speed(int(cx)+50, int(cy)); s != wg.WaveSpeed() {
+		t.Errorf("speed in the slow region = %g, want the open water's %g", s, wg.WaveSpeed())
+	}
+	if len(wg.Pins) != 0 || g.stepCount != 0 {
+		t.Errorf("%d pins left and step count %d, want none and 0", len(wg.Pins), g.stepCount)
+	}
+}
+
+// TestReplayRoundTrip saves the golden impulses as an events file, reads them
+// back and replays them. The replay must end on the same field hash as the
+// run they were saved from.
+func TestReplayRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteEvents(&buf, goldenImpulses); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadEvents(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(events, goldenImpulses) {
+		t.Fatalf("read back %v, want %v", events, goldenImpulses)
+	}
+	want := runHeadlessGrid(DefaultConfig(), goldenSteps, goldenImpulses).FieldHash()
+	if got := runHeadlessGrid(DefaultConfig(), goldenSteps, events).FieldHash(); got != want {
+		t.Errorf("replayed events give field hash %#016x, the run they were saved from %#016x", got, want)
+	}
+}
+
+// TestReadEventsErrors reads broken events files. Each must fail, naming the
+// line at fault.
+func TestReadEventsErrors(t *testing.T) {
+	for _, c := range []struct {
+		name, text, line string
+	}{
+		{"missing radius", "# step x y radius\n0 500 300\n", "line 2:"},
+		{"steps backwards", "10 500 300 8\n\n5 500 300 8\n", "line 3:"},
+		{"negative step", "-1 500 300 8\n", "line 1:"},
+		{"zero radius", "0 500 300 8\n0 500 300 0\n", "line 2:"},
+		{"negative radius", "# step x y radius\n0 500 300 -4\n", "line 2:"},
+		{"NaN radius", "0 500 300 NaN\n", "line 1:"},
+	} {
+		_, err := ReadEvents(strings.NewReader(c.text))
+		if err == nil || !strings.HasPrefix(err.Error(), c.line) {
+			t.Errorf("%s: error %v, want one starting with %q", c.name, err, c.line)
+		}
+	}
+}
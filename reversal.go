@@ -0,0 +1,122 @@
+package main
+
+import "game/wavegrid"
+
+const (
+	reverseInterval = 30        // solver steps between the snapshots kept for reversing
+	maxReverseSteps = 3000      // solver steps that can be run back, 10 s at the default rate
+	reverseMemory   = 128 << 20 // bytes the snapshots may take, which shortens the reach on big ponds
+)
+
+// Reversal keeps a ring buffer of snapshots of the field, one every
+// reverseInterval solver steps, so the waves can be run backward to where
+// they came from. The solver undoes itself with StepBack between snapshots,
+// and each snapshot it reaches replaces the field to wipe out the rounding
+// error run backward since the last one, which damping amplifies.
+type Reversal struct {
+	width     int // size of the grid the snapshots were taken of, in cells
+	height    int
+	cells     []int       // indices of the pond cells when recording started
+	snapshots [][]float64 // heights then velocities of cells, oldest first from start
+	start     int         // index of the oldest snapshot in snapshots
+	count     int         // snapshots held
+	since     int         // solver steps taken since the newest snapshot
+	reversing bool
+}
+
+// capacity returns how many snapshots of cells fit the limits.
+func (r *Reversal) capacity(cells int) int {
+	byMemory := reverseMemory / max(1, 16*cells)
+	return max(1, min(maxReverseSteps/reverseInterval, byMemory))
+}
+
+// reset drops every snapshot and starts recording the pond of wg afresh.
+func (r *Reversal) reset(wg *wavegrid.Grid) {
+	r.cells = r.cells[:0]
+	r.width, r.height = wg.Size()
+	for y := range r.height {
+		for x := range r.width {
+			if wg.InMask(x, y) {
+				r.cells = append(r.cells, wg.Index(x, y))
+			}
+		}
+	}
+	r.snapshots = make([][]float64, r.capacity(len(r.cells)))
+	r.start, r.count, r.since = 0, 0, 0
+	r.reversing = false
+}
+
+// matches reports whether the snapshots were taken of a grid like wg, whose
+// cells can be restored from them.
+func (r *Reversal) matches(wg *wavegrid.Grid) bool {
+	width, height := wg.Size()
+	return r.snapshots != nil && width == r.width && height == r.height
+}
+
+// recorded notes that wg took a step forward, taking a snapshot every
+// reverseInterval steps and overwriting the oldest one once full.
+func (r *Reversal) recorded(wg *wavegrid.Grid) {
+	if !r.matches(wg) {
+		r.reset(wg)
+	}
+	r.since++
+	if r.since < reverseInterval {
+		return
+	}
+	r.since = 0
+
+	i := (r.start + r.count) % len(r.snapshots)
+	if r.count == len(r.snapshots) {
+		r.start = (r.start + 1) % len(r.snapshots)
+	} else {
+		r.count++
+	}
+	heights, velocities := wg.Heights(), wg.Velocities()
+	snapshot := r.snapshots[i][:0]
+	for _, c := range r.cells {
+		snapshot = append(snapshot, heights[c])
+	}
+	for _, c := range r.cells {
+		snapshot = append(snapshot, velocities[c])
+	}
+	r.snapshots[i] = snapshot
+}
+
+// stepBack runs wg one step back, first restoring the newest snapshot if the
+// field has got back to it. It returns false, leaving wg alone, once the
+// oldest snapshot has been reached and there is nothing earlier to go to.
+func (r *Reversal) stepBack(wg *wavegrid.Grid) bool {
+	if !r.matches(wg) {
+		r.reset(wg)
+		return false
+	}
+	if r.since == 0 {
+		if r.count == 0 {
+			return false
+		}
+		r.count--
+		snapshot := r.snapshots[(r.start+r.count)%len(r.snapshots)]
+		heights, velocities := wg.Heights(), wg.Velocities()
+		for j, c := range r.cells {
+			heights[c] = snapshot[j]
+			velocities[c] = snapshot[len(r.cells)+j]
+		}
+		if r.count == 0 {
+			return false
+		}
+		r.since = reverseInterval
+	}
+	wg.StepBack()
+	r.since--
+	return true
+}
+
+// seconds returns how far back the field can currently be run, with solver
+// steps of dt seconds: back to the newest snapshot, then on to the oldest.
+func (r *Reversal) seconds(dt float64) float64 {
+	steps := r.since
+	if r.count > 0 {
+		steps += (r.count - 1) * reverseInterval
+	}
+	return float64(steps) * dt
+}
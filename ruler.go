@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"game/wavegrid"
+)
+
+const rulerGrabRadius = 8.0 // pixels around an endpoint that pick it up
+
+// Ruler is a measuring line with two draggable endpoints, for reading the
+// ripple spacing off the screen.
+type Ruler struct {
+	ends     [2]wavegrid.Vector2
+	dragging int // index of the end being dragged, or -1
+}
+
+func NewRuler(a, b wavegrid.Vector2) *Ruler {
+	return &Ruler{ends: [2]wavegrid.Vector2{a, b}, dragging: -1}
+}
+
+// grab picks up the endpoint near (x, y), if any.
+func (r *Ruler) grab(x, y float64) {
+	for i, end := range r.ends {
+		if end.Sub(wavegrid.Vector2{X: x, Y: y}).Length() <= rulerGrabRadius {
+			r.dragging = i
+			return
+		}
+	}
+}
+
+// move drags the grabbed endpoint, if any, to (x, y).
+func (r *Ruler) move(x, y float64) {
+	if r.dragging >= 0 {
+		r.ends[r.dragging] = wavegrid.Vector2{X: x, Y: y}
+	}
+}
+
+func (r *Ruler) release() {
+	r.dragging = -1
+}
+
+// draw strokes the ruler and labels it with its length in pixels and in
+// wavelengths of the given size.
+func (r *Ruler) draw(screen *ebiten.Image, wavelength float64) {
+	a, b := r.ends[0], r.ends[1]
+	c := color.RGBA{255, 255, 120, 255}
+	vector.StrokeLine(screen, float32(a.X), float32(a.Y), float32(b.X), float32(b.Y), 1, c, false)
+	for _, end := range r.ends {
+		vector.StrokeCircle(screen, float32(end.X), float32(end.Y), rulerGrabRadius/2, 1, c, false)
+	}
+
+	length := b.Sub(a).Length()
+	mid := a.Add(b).Scale(0.5)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.1f px = %.2f x %.0f px", length, length/wavelength, wavelength), int(mid.X)+6, int(mid.Y)+6)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"game/wavegrid"
+)
+
+// configShapes returns the pond outlines for cfg: the outline in
+// cfg.ShapeFile if set, otherwise the layout named by cfg.Shape, centered on
+// the screen and sized by cfg.ShapeRadius.
+func configShapes(cfg Config) ([]wavegrid.Shape, error) {
+	if cfg.ShapeFile != "" {
+		shape, err := wavegrid.LoadShape(cfg.ShapeFile)
+		if err != nil {
+			return nil, err
+		}
+		return []wavegrid.Shape{shape}, nil
+	}
+
+	cx, cy := float64(cfg.ScreenWidth)/2, float64(cfg.ScreenHeight)/2
+	r := cfg.ShapeRadius
+	for _, s := range shapeRegistry {
+		if s.name == cfg.Shape {
+			return []wavegrid.Shape{s.generate(cx, cy, r)}, nil
+		}
+	}
+	switch cfg.Shape {
+	case "open":
+		// Water over the whole grid, reaching past every border cell, for
+		// a periodic grid without edges
+		margin := 2 * float64(cfg.GridSize)
+		return []wavegrid.Shape{
+			wavegrid.Rect(cx, cy, float64(cfg.ScreenWidth)+margin, float64(cfg.ScreenHeight)+margin),
+		}, nil
+	case "box":
+		// Water over the screen inside a margin, for the presets that need
+		// the room
+		return []wavegrid.Shape{
+			wavegrid.Rect(cx, cy, float64(cfg.ScreenWidth)-2*boxMargin, float64(cfg.ScreenHeight)-2*boxMargin),
+		}, nil
+	case "ponds":
+		// Two ponds separated by a strip of land
+		return []wavegrid.Shape{
+			wavegrid.Circle(cx-r*13/15, cy, r*2/3),
+			wavegrid.Circle(cx+r*13/15, cy, r*2/3),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown shape %q", cfg.Shape)
+}
+
+// shapeRegistry lists the single-pond layouts, in the order Shift+1 to
+// Shift+5 select them in grid mode. Each fits in a circle of radius r.
+var shapeRegistry = []struct {
+	name     string
+	generate func(cx, cy, r float64) wavegrid.Shape
+}{
+	{"circle", wavegrid.Circle},
+	{"rect", func(cx, cy, r float64) wavegrid.Shape { return wavegrid.Rect(cx, cy, 2*r, 4*r/3) }},
+	{"triangle", wavegrid.Triangle},
+	{"star", func(cx, cy, r float64) wavegrid.Shape { return wavegrid.Star(cx, cy, r, starInnerRatio*r, starPoints) }},
+	{"hexagon", wavegrid.Hexagon},
+}
+
+const (
+	starPoints     = 5
+	starInnerRatio = 0.45 // radius of the inner corners relative to the tips
+)
+
+// boxMargin is the land left around the "box" pond, in pixels.
+const boxMargin = 10.0
+
+// edgeTolerance is how far outside the outline, in pixels, a click still
+// lands, so clicks right on the drawn edge aren't silently dropped.
+const edgeTolerance = 3.0
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"game/wavegrid"
+)
+
+const (
+	panelWidth     = 220
+	panelPadding   = 8
+	sliderSpacing  = 36 // pixels from one slider's label to the next
+	sliderTrack    = 10 // height of the track, below the label
+	sliderKnobSize = 4  // half width of the knob
+)
+
+// Slider is a horizontal track the value is dragged along, from min at the
+// left end of rect to max at the right.
+type Slider struct {
+	min, max float64
+	value    float64
+	rect     image.Rectangle
+	dragging bool
+}
+
+// update moves the value to the cursor while the track is dragged, starting
+// with a press on it. It reports whether the value changed.
+func (s *Slider) update(mx, my int) bool {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && image.Pt(mx, my).In(s.rect) {
+		s.dragging = true
+	}
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		s.dragging = false
+	}
+	if !s.dragging {
+		return false
+	}
+	t := float64(mx-s.rect.Min.X) / float64(s.rect.Dx())
+	value := s.min + math.Max(0, math.Min(1, t))*(s.max-s.min)
+	changed := value != s.value
+	s.value = value
+	return changed
+}
+
+func (s *Slider) draw(screen *ebiten.Image) {
+	x, y := float32(s.rect.Min.X), float32(s.rect.Min.Y)
+	w, h := float32(s.rect.Dx()), float32(s.rect.Dy())
+	t := float32((s.value - s.min) / (s.max - s.min))
+	t = max(0, min(1, t))
+	vector.FillRect(screen, x, y, w, h, color.RGBA{60, 60, 70, 255}, false)
+	vector.FillRect(screen, x, y, w*t, h, theme.Boundary, false)
+	vector.FillRect(screen, x+w*t-sliderKnobSize, y-2, 2*sliderKnobSize, h+4, color.White, false)
+}
+
+// panelControl ties a slider to the setting it adjusts. get reads the setting
+// back every frame, so keys changing it move the slider too.
+type panelControl struct {
+	label  string
+	format string // fmt verb for the value next to the label
+	slider Slider
+	get    func() float64
+	set    func(float64)
+}
+
+// ControlPanel is a column of sliders in the top-right corner of the screen.
+type ControlPanel struct {
+	controls []*panelControl
+	rect     image.Rectangle // the panel background, which clicks don't pass through
+}
+
+// NewControlPanel lays out controls top to bottom with the panel's top-right
+// corner at (right, top).
+func NewControlPanel(right, top int, controls []*panelControl) *ControlPanel {
+	p := &ControlPanel{controls: controls}
+	left := right - panelWidth
+	for i, c := range controls {
+		y := top + panelPadding + i*sliderSpacing + debugLineHeight
+		c.slider.rect = image.Rect(left+panelPadding, y, right-panelPadding, y+sliderTrack)
+		c.slider.value = c.get()
+	}
+	p.rect = image.Rect(left, top, right, top+2*panelPadding+len(controls)*sliderSpacing)
+	return p
+}
+
+// update drags the sliders, writing every change through to its setting, and
+// reports whether the cursor is over the panel or dragging one, when clicks
+// are the panel's and shouldn't reach the water below.
+func (p *ControlPanel) update() bool {
+	mx, my := ebiten.CursorPosition()
+	captured := image.Pt(mx, my).In(p.rect)
+	for _, c := range p.controls {
+		if !c.slider.dragging {
+			c.slider.value = c.get()
+		}
+		if c.slider.update(mx, my) {
+			c.set(c.slider.value)
+		}
+		captured = captured || c.slider.dragging
+	}
+	return captured
+}
+
+func (p *ControlPanel) draw(screen *ebiten.Image) {
+	r := p.rect
+	vector.FillRect(screen, float32(r.Min.X), float32(r.Min.Y), float32(r.Dx()), float32(r.Dy()), color.RGBA{0, 0, 0, 180}, false)
+	for _, c := range p.controls {
+		label := fmt.Sprintf("%s: "+c.format, c.label, c.slider.value)
+		ebitenutil.DebugPrintAt(screen, label, c.slider.rect.Min.X, c.slider.rect.Min.Y-debugLineHeight)
+		c.slider.draw(screen)
+	}
+}
+
+// newControlPanel returns the sliders of grid mode, for the settings that
+// otherwise take several key presses to tune.
+func (g *Game) newControlPanel() *ControlPanel {
+	return NewControlPanel(g.cfg.ScreenWidth-graphMargin, 56, []*panelControl{
+		{
+			label: "Speed", format: "%.2f cells/step",
+			slider: Slider{min: wavegrid.MinWaveSpeed, max: wavegrid.MaxWaveSpeed},
+			get:    func() float64 { return g.waveGrid.WaveSpeed() },
+			set: func(v float64) {
+				g.waveGrid.SetWaveSpeed(v)
+				g.cfg.WaveSpeed = g.waveGrid.WaveSpeed()
+			},
+		},
+		{
+			label: "Damping", format: "%.3f",
+			slider: Slider{min: minDamping, max: maxDamping},
+			get:    func() float64 { return g.waveGrid.Damping() },
+			set: func(v float64) {
+				g.waveGrid.SetDamping(v)
+				g.cfg.Damping = v
+			},
+		},
+		{
+			label: "Paddle wavelength", format: "%.1f cells",
+			slider: Slider{min: minPaddleWavelength, max: maxPaddleWavelength},
+			get:    func() float64 { return g.paddleWaveSpeed() / g.paddleFrequency },
+			set:    func(v float64) { g.setPaddleFrequency(g.paddleWaveSpeed() / v) },
+		},
+		{
+			label: "Paddle amplitude", format: "%.1f",
+			slider: Slider{min: minPaddleAmplitude, max: maxPaddleAmplitude},
+			get:    func() float64 { return g.paddleAmplitude },
+			set: func(v float64) {
+				g.paddleAmplitude = v
+				if p := g.waveGrid.Paddle; p != nil {
+					p.Amplitude = v
+				}
+			},
+		},
+		{
+			label: "Reflectivity", format: "%.2f",
+			slider: Slider{min: 0, max: 1},
+			get:    func() float64 { return g.waveGrid.Reflectivity },
+			set: func(v float64) {
+				g.waveGrid.Reflectivity = v
+				g.cfg.Reflectivity = v
+			},
+		},
+		{
+			label: "Brush radius", format: "%.0f cells",
+			slider: Slider{min: minBrushRadius, max: maxBrushRadius},
+			get:    func() float64 { return g.brushRadius },
+			set:    func(v float64) { g.brushRadius = math.Round(v) },
+		},
+	})
+}
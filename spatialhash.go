@@ -0,0 +1,51 @@
+package main
+
+import "math"
+
+// SpatialHash bins points into square cells so neighbor queries only look at
+// the few cells around a point instead of every point. Points are identified
+// by an index the caller chooses, usually their position in a slice.
+type SpatialHash struct {
+	cellSize float64
+	cells    map[[2]int][]int
+	near     []int // reused result of Near
+}
+
+func NewSpatialHash(cellSize float64) *SpatialHash {
+	return &SpatialHash{cellSize: cellSize, cells: make(map[[2]int][]int)}
+}
+
+func (h *SpatialHash) cell(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / h.cellSize)), int(math.Floor(y / h.cellSize))}
+}
+
+// Insert adds point id at (x, y).
+func (h *SpatialHash) Insert(id int, x, y float64) {
+	c := h.cell(x, y)
+	h.cells[c] = append(h.cells[c], id)
+}
+
+// Clear empties the hash, keeping the cell slices for the next rebuild.
+func (h *SpatialHash) Clear() {
+	for c, ids := range h.cells {
+		if len(ids) == 0 {
+			delete(h.cells, c) // nothing landed here last time, let it go
+			continue
+		}
+		h.cells[c] = ids[:0]
+	}
+}
+
+// Near returns the ids of every point in the cells overlapping the square of
+// half-side r around (x, y). It is a candidate list: callers still check the
+// exact distance. The slice is reused by the next call.
+func (h *SpatialHash) Near(x, y, r float64) []int {
+	h.near = h.near[:0]
+	lo, hi := h.cell(x-r, y-r), h.cell(x+r, y+r)
+	for cy := lo[1]; cy <= hi[1]; cy++ {
+		for cx := lo[0]; cx <= hi[0]; cx++ {
+			h.near = append(h.near, h.cells[[2]int{cx, cy}]...)
+		}
+	}
+	return h.near
+}
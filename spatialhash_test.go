@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"game/wavegrid"
+)
+
+// randomPoints scatters n points over the default screen with a fixed seed.
+func randomPoints(n int) []wavegrid.Vector2 {
+	cfg := DefaultConfig()
+	rng := rand.New(rand.NewPCG(1, 1))
+	points := make([]wavegrid.Vector2, n)
+	for i := range points {
+		points[i] = wavegrid.Vector2{X: rng.Float64() * float64(cfg.ScreenWidth), Y: rng.Float64() * float64(cfg.ScreenHeight)}
+	}
+	return points
+}
+
+// bruteNeighbors returns the indices of the points within r of p, checking
+// every one.
+func bruteNeighbors(points []wavegrid.Vector2, p wavegrid.Vector2, r float64, out []int) []int {
+	out = out[:0]
+	for j, q := range points {
+		if p.Sub(q).Length() < r {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// hashNeighbors is bruteNeighbors over the candidates of h.
+func hashNeighbors(h *SpatialHash, points []wavegrid.Vector2, p wavegrid.Vector2, r float64, out []int) []int {
+	out = out[:0]
+	for _, j := range h.Near(p.X, p.Y, r) {
+		if p.Sub(points[j]).Length() < r {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// TestSpatialHashNear finds the neighbors of every point of a dense scatter
+// through the hash and by checking every point. Both must find the same ones.
+func TestSpatialHashNear(t *testing.T) {
+	const r = 10.0
+	points := randomPoints(2000)
+	h := NewSpatialHash(r)
+	for i, p := range points {
+		h.Insert(i, p.X, p.Y)
+	}
+	var want, got []int
+	for i, p := range points {
+		want = bruteNeighbors(points, p, r, want)
+		got = hashNeighbors(h, points, p, r, got)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Fatalf("neighbors of point %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+// BenchmarkNeighbors finds the colliding neighbors of every particle, as
+// collideParticles does each tick, through the hash and by checking every
+// pair, for growing particle counts. Rebuilding the hash and querying it
+// grows linearly with the count; the brute force grows with its square.
+func BenchmarkNeighbors(b *testing.B) {
+	const r = 2 * particleRadius
+	for _, n := range []int{500, 2000, 8000} {
+		points := randomPoints(n)
+		h := NewSpatialHash(r)
+		for i, p := range points {
+			h.Insert(i, p.X, p.Y)
+		}
+		var out []int
+
+		b.Run(fmt.Sprintf("insert-%d", n), func(b *testing.B) {
+			for range b.N {
+				h.Clear()
+				for i, p := range points {
+					h.Insert(i, p.X, p.Y)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("query-%d", n), func(b *testing.B) {
+			for range b.N {
+				for _, p := range points {
+					out = hashNeighbors(h, points, p, r, out)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("brute-%d", n), func(b *testing.B) {
+			for range b.N {
+				for _, p := range points {
+					out = bruteNeighbors(points, p, r, out)
+				}
+			}
+		})
+	}
+}
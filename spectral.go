@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"game/wavegrid"
+)
+
+// SpectralGrid solves the wave equation on a periodic rectangle in Fourier
+// space. Each Fourier mode of the wave equation is an independent harmonic
+// oscillator, so Step advances every mode by an exact rotation of its phase:
+// there is no numerical dispersion and no stability limit on the speed, unlike
+// the finite-difference WaveGrid. Waves leaving one side come back on the other.
+type SpectralGrid struct {
+	width, height int     // cells, both powers of two
+	gridSize      float64 // pixels per cell
+	waveSpeed     float64 // cells per step
+	damping       float64
+
+	// Fourier coefficients of the height and of its time derivative
+	heightHat, velocityHat []complex128
+
+	// Per-mode angular frequency and the rotation it makes in one step
+	omega, stepCos, stepSin []float64
+
+	heights []float64 // real-space heights, refreshed lazily from heightHat
+	stale   bool      // heightHat changed since heights was computed
+
+	scratch, column []complex128
+}
+
+// NewSpectralGrid creates a width×height periodic grid, dimensions in cells.
+// waveSpeed is in cells per step.
+func NewSpectralGrid(width, height int, gridSize, waveSpeed, damping float64) (*SpectralGrid, error) {
+	if !isPowerOfTwo(width) || !isPowerOfTwo(height) {
+		return nil, fmt.Errorf("spectral grid must be a power of two on each side, got %dx%d", width, height)
+	}
+	n := width * height
+	sg := &SpectralGrid{
+		width:       width,
+		height:      height,
+		gridSize:    gridSize,
+		damping:     damping,
+		heightHat:   make([]complex128, n),
+		velocityHat: make([]complex128, n),
+		omega:       make([]float64, n),
+		stepCos:     make([]float64, n),
+		stepSin:     make([]float64, n),
+		heights:     make([]float64, n),
+		scratch:     make([]complex128, n),
+		column:      make([]complex128, height),
+	}
+	sg.setWaveSpeed(waveSpeed)
+	return sg, nil
+}
+
+// wavenumber returns the signed wavenumber in radians per cell of FFT index i
+// on an axis of n cells.
+func wavenumber(i, n int) float64 {
+	if i > n/2 {
+		i -= n
+	}
+	return 2 * math.Pi * float64(i) / float64(n)
+}
+
+// setWaveSpeed changes the speed and recomputes the per-mode rotations.
+func (sg *SpectralGrid) setWaveSpeed(waveSpeed float64) {
+	sg.waveSpeed = math.Max(wavegrid.MinWaveSpeed, waveSpeed)
+	for ky := 0; ky < sg.height; ky++ {
+		for kx := 0; kx < sg.width; kx++ {
+			i := ky*sg.width + kx
+			sg.omega[i] = sg.waveSpeed * math.Hypot(wavenumber(kx, sg.width), wavenumber(ky, sg.height))
+			sg.stepCos[i], sg.stepSin[i] = math.Cos(sg.omega[i]), math.Sin(sg.omega[i])
+		}
+	}
+}
+
+// Step advances every mode by one time step.
+func (sg *SpectralGrid) Step() {
+	for i, w := range sg.omega {
+		h, v := sg.heightHat[i], sg.velocityHat[i]
+		if w == 0 {
+			// The mean level just drifts with the mean velocity
+			sg.heightHat[i] = h + v
+			continue
+		}
+		c, s := complex(sg.stepCos[i], 0), complex(sg.stepSin[i], 0)
+		wc := complex(w, 0)
+		sg.heightHat[i] = h*c + v*s/wc
+		sg.velocityHat[i] = (v*c - h*wc*s) * complex(sg.damping, 0)
+	}
+	sg.stale = true
+}
+
+// addWave adds a velocity impulse of radius cells around the pixel position
+// (mx, my), with the same profile as the grid's default brush. The footprint
+// wraps around the edges.
+func (sg *SpectralGrid) addWave(mx, my, radius float64) {
+	gridX, gridY := int(mx/sg.gridSize), int(my/sg.gridSize)
+	clear(sg.scratch)
+	for dy := -int(radius); dy <= int(radius); dy++ {
+		for dx := -int(radius); dx <= int(radius); dx++ {
+			dist := math.Sqrt(float64(dx*dx + dy*dy))
+			if dist <= radius {
+				x := ((gridX+dx)%sg.width + sg.width) % sg.width
+				y := ((gridY+dy)%sg.height + sg.height) % sg.height
+				sg.scratch[y*sg.width+x] += complex(wavegrid.ImpulseStrength*wavegrid.ImpulseProfile(wavegrid.Quadratic, dist/radius), 0)
+			}
+		}
+	}
+
+	// The transform is linear, so the impulse adds directly to the coefficients
+	fft2(sg.scratch, sg.width, sg.height, false, sg.column)
+	for i, c := range sg.scratch {
+		sg.velocityHat[i] += c
+	}
+}
+
+// setHeights replaces the state with the given real-space heights, at rest.
+func (sg *SpectralGrid) setHeights(heights []float64) {
+	for i, h := range heights {
+		sg.scratch[i] = complex(h, 0)
+	}
+	fft2(sg.scratch, sg.width, sg.height, false, sg.column)
+	copy(sg.heightHat, sg.scratch)
+	clear(sg.velocityHat)
+	sg.stale = true
+}
+
+// realHeights returns the heights in real space, transforming back from
+// Fourier space only when they changed.
+func (sg *SpectralGrid) realHeights() []float64 {
+	if sg.stale {
+		copy(sg.scratch, sg.heightHat)
+		fft2(sg.scratch, sg.width, sg.height, true, sg.column)
+		for i, c := range sg.scratch {
+			sg.heights[i] = real(c)
+		}
+		sg.stale = false
+	}
+	return sg.heights
+}
+
+func (sg *SpectralGrid) heightAt(x, y int) float64 {
+	if x < 0 || x >= sg.width || y < 0 || y >= sg.height {
+		return 0
+	}
+	return sg.realHeights()[y*sg.width+x]
+}
+
+func (sg *SpectralGrid) maxHeight() float64 {
+	maxH := 0.0
+	for _, h := range sg.realHeights() {
+		maxH = math.Max(maxH, math.Abs(h))
+	}
+	return maxH
+}
+
+// ClearField brings the water back to rest.
+func (sg *SpectralGrid) ClearField() {
+	clear(sg.heightHat)
+	clear(sg.velocityHat)
+	sg.stale = true
+}
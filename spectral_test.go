@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSpectralMode evolves a single periodic mode on a SpectralGrid and
+// compares it with the exact solution cos(k·x)·cos(ωt). The spectral solver
+// has no dispersion, so only rounding error is allowed.
+func TestSpectralMode(t *testing.T) {
+	const size, steps, tolerance = 64, 2000, 1e-9
+	sg, err := NewSpectralGrid(size, size, 1, 0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kx, ky := wavenumber(5, size), wavenumber(2, size)
+	mode := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			mode[y*size+x] = math.Cos(kx*float64(x)) * math.Cos(ky*float64(y))
+		}
+	}
+	sg.setHeights(mode)
+	for range steps {
+		sg.Step()
+	}
+
+	phase := math.Cos(sg.waveSpeed * math.Hypot(kx, ky) * steps)
+	worst := 0.0
+	for i, h := range sg.realHeights() {
+		worst = math.Max(worst, math.Abs(h-mode[i]*phase))
+	}
+	if worst > tolerance {
+		t.Errorf("spectral mode (5,2) drifted by %.2e after %d steps, want at most %g", worst, steps, tolerance)
+	}
+}
+
+// BenchmarkSpectralStep times one Step of a spectral grid close to the size
+// of the default pond, with the powers of two NewSpectralGrid requires.
+func BenchmarkSpectralStep(b *testing.B) {
+	sg, err := NewSpectralGrid(1024, 512, 1, 0.5, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sg.addWave(512, 256, defaultBrushRadius)
+	b.ResetTimer()
+	for range b.N {
+		sg.Step()
+	}
+}
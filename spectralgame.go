@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	spectralCells    = 256 // cells per side of the periodic domain
+	spectralCellSize = 2   // pixels per cell
+)
+
+// SpectralGame runs a SpectralGrid on a periodic square in the middle of the
+// screen, for comparing its dispersion-free waves with the grid solver.
+type SpectralGame struct {
+	cfg       Config
+	grid      *SpectralGrid
+	renderer  fieldRenderer
+	normValue float64
+	paused    bool
+	timestep  fixedTimestep
+	touchIDs  []ebiten.TouchID // reused buffer for new touches
+
+	offsetX, offsetY float64 // screen position of the domain's top-left corner
+}
+
+func NewSpectralGame(cfg Config) (*SpectralGame, error) {
+	// WaveGrid's stencil moves long waves at √(3/8) of its nominal speed, so
+	// scale to match it on screen
+	speed := cfg.WaveSpeed * math.Sqrt(3.0/8.0) * float64(cfg.GridSize) / spectralCellSize
+	grid, err := NewSpectralGrid(spectralCells, spectralCells, spectralCellSize, speed, cfg.Damping)
+	if err != nil {
+		return nil, err
+	}
+	side := float64(spectralCells * spectralCellSize)
+	return &SpectralGame{
+		cfg:       cfg,
+		grid:      grid,
+		normValue: minNormValue,
+		timestep:  fixedTimestep{dt: cfg.stepTime()},
+		offsetX:   (float64(cfg.ScreenWidth) - side) / 2,
+		offsetY:   (float64(cfg.ScreenHeight) - side) / 2,
+	}, nil
+}
+
+// poke adds an impulse at screen position (x, y) if it is inside the domain.
+func (g *SpectralGame) poke(x, y int) {
+	px, py := float64(x)-g.offsetX, float64(y)-g.offsetY
+	side := float64(spectralCells * spectralCellSize)
+	if px >= 0 && px < side && py >= 0 && py < side {
+		g.grid.addWave(px, py, defaultBrushRadius/spectralCellSize)
+	}
+}
+
+func (g *SpectralGame) Update() error {
+	updateTheme()
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		g.poke(ebiten.CursorPosition())
+	}
+	// A touch pokes once when it lands instead of every frame it is held
+	g.touchIDs = inpututil.AppendJustPressedTouchIDs(g.touchIDs[:0])
+	for _, id := range g.touchIDs {
+		g.poke(ebiten.TouchPosition(id))
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		g.grid.ClearField()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.paused = !g.paused
+	}
+
+	if !g.paused {
+		for range g.timestep.steps() {
+			g.grid.Step()
+		}
+	} else {
+		g.timestep.reset()
+		if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+			g.grid.Step()
+		}
+	}
+
+	g.normValue = math.Max(minNormValue, g.grid.maxHeight())
+	return nil
+}
+
+func (g *SpectralGame) Draw(screen *ebiten.Image) {
+	screen.Fill(theme.Background)
+
+	sg := g.grid
+	r := &g.renderer
+	if r.image == nil {
+		r.image = ebiten.NewImage(sg.width, sg.height)
+		r.pixels = make([]byte, 4*sg.width*sg.height)
+	}
+	for i, h := range sg.realHeights() {
+		c := heightColor(colorMaps[0], h, g.normValue)
+		r.pixels[4*i], r.pixels[4*i+1], r.pixels[4*i+2], r.pixels[4*i+3] = c.R, c.G, c.B, c.A
+	}
+	r.image.WritePixels(r.pixels)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(sg.gridSize, sg.gridSize)
+	op.GeoM.Translate(g.offsetX, g.offsetY)
+	screen.DrawImage(r.image, op)
+
+	side := float32(float64(sg.width) * sg.gridSize)
+	vector.StrokeRect(screen, float32(g.offsetX), float32(g.offsetY), side, side, 2, theme.Boundary, false)
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %.2f\nSpectral solver on a periodic square: waves leaving one side come back on the other\nClick to create waves | R to reset | Space to pause, . to step | Shift+K for the theme", ebiten.CurrentTPS()))
+	if g.paused {
+		ebitenutil.DebugPrintAt(screen, "PAUSED", 0, 48)
+	}
+}
+
+func (g *SpectralGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return g.cfg.ScreenWidth, g.cfg.ScreenHeight
+}
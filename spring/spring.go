@@ -0,0 +1,90 @@
+// Package spring integrates the spring-mass ring of the _mainx13.go variant:
+// particles along a pond outline, each pulled back to where it started and
+// vertically toward its two neighbors. It has no rendering, so the variant
+// draws the ring and the tests step it headless.
+package spring
+
+import "math"
+
+const (
+	WaveDamping  = 0.995 // velocity kept per step while damped
+	SpringForce  = 0.05  // pull back to the original position
+	Spread       = 0.2   // coupling to the two neighbors
+	MaxSpreadAmp = 100   // particles displaced further than this stop pulling their neighbors
+)
+
+// Particle is one mass of the ring.
+type Particle struct {
+	X, Y         float64
+	VX, VY       float64
+	OrigX, OrigY float64
+	OnShape      bool
+}
+
+// Ring is a closed chain of particles, each coupled to the one before and
+// the one after it.
+type Ring struct {
+	Particles []*Particle
+	Damped    bool // lose WaveDamping of the velocity each step
+}
+
+// acceleration returns the force on particle i at the current positions: the
+// spring to its original position plus the vertical pull of its neighbors.
+func (r *Ring) acceleration(i int) (ax, ay float64) {
+	p := r.Particles[i]
+	ax = (p.OrigX - p.X) * SpringForce
+	ay = (p.OrigY - p.Y) * SpringForce
+
+	// Propagate wave to neighbors
+	leftP := r.Particles[(i-1+len(r.Particles))%len(r.Particles)]
+	rightP := r.Particles[(i+1)%len(r.Particles)]
+	if math.Abs(p.Y-p.OrigY) < MaxSpreadAmp { // Only propagate if not too large
+		ay += Spread * (leftP.Y - p.Y)
+		ay += Spread * (rightP.Y - p.Y)
+	}
+	return ax, ay
+}
+
+// Update advances one step with semi-implicit (symplectic) Euler: every
+// velocity is kicked by the forces at the current positions, then every
+// position drifts with its new velocity. Taking all forces before anything
+// moves keeps the neighbor coupling symmetric, so without damping the energy
+// oscillates slightly around its true value instead of drifting away.
+func (r *Ring) Update() {
+	for i, p := range r.Particles {
+		if !p.OnShape {
+			continue
+		}
+		ax, ay := r.acceleration(i)
+		p.VX += ax
+		p.VY += ay
+		if r.Damped {
+			p.VX *= WaveDamping
+			p.VY *= WaveDamping
+		}
+	}
+	for _, p := range r.Particles {
+		if p.OnShape {
+			p.X += p.VX
+			p.Y += p.VY
+		}
+	}
+}
+
+// Energy returns the kinetic energy plus the potential stored in the springs
+// and the neighbor coupling, which Update conserves when undamped.
+func (r *Ring) Energy() float64 {
+	e := 0.0
+	for i, p := range r.Particles {
+		if !p.OnShape {
+			continue
+		}
+		dx, dy := p.X-p.OrigX, p.Y-p.OrigY
+		e += 0.5 * (p.VX*p.VX + p.VY*p.VY)
+		e += 0.5 * SpringForce * (dx*dx + dy*dy)
+		// Each neighbor pair once
+		rightP := r.Particles[(i+1)%len(r.Particles)]
+		e += 0.5 * Spread * (rightP.Y - p.Y) * (rightP.Y - p.Y)
+	}
+	return e
+}
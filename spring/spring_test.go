@@ -0,0 +1,35 @@
+package spring
+
+import (
+	"math"
+	"testing"
+)
+
+// TestUndampedOscillatorEnergy swings a single undamped particle on its
+// spring, with no neighbors to pull it, for many periods. Symplectic Euler
+// doesn't hold the energy ½(v² + k·x²) exactly, but the nearby
+// ½(v² + k·x² - k·x·v) to rounding error, and the two never differ by more
+// than ω/2 of the energy, ω = √SpringForce per step. So the swing neither
+// grows nor dies out however long it runs.
+func TestUndampedOscillatorEnergy(t *testing.T) {
+	p := &Particle{X: 110, Y: 100, OrigX: 100, OrigY: 100, VY: 3, OnShape: true}
+	r := &Ring{Particles: []*Particle{p}}
+	shadow := func() float64 {
+		dx, dy := p.X-p.OrigX, p.Y-p.OrigY
+		return 0.5 * (p.VX*p.VX + p.VY*p.VY + SpringForce*(dx*dx+dy*dy) - SpringForce*(dx*p.VX+dy*p.VY))
+	}
+
+	const steps = 100000
+	start := shadow()
+	swing := math.Sqrt(SpringForce) / 2
+	for i := range steps {
+		r.Update()
+		s := shadow()
+		if math.Abs(s-start) > 1e-9*start {
+			t.Fatalf("step %d: shadow energy %g, want %g", i, s, start)
+		}
+		if e := r.Energy(); math.Abs(e-s) > swing*e {
+			t.Fatalf("step %d: energy %g, want within %.1f%% of the shadow energy %g", i, e, 100*swing, s)
+		}
+	}
+}
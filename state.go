@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"game/wavegrid"
+)
+
+// stateFile is where F5 saves the simulation and F9 loads it from.
+const stateFile = "wavegrid.gob"
+
+// savedLabel is the gob form of a Label, with its source given by index into
+// the saved grid's Pins, -1 for none.
+type savedLabel struct {
+	X, Y   float64
+	Text   string
+	Pin    int
+	DX, DY float64
+}
+
+// saveState writes the grid to stateFile, followed by the labels.
+func (g *Game) saveState() error {
+	f, err := os.Create(stateFile)
+	if err != nil {
+		return err
+	}
+	if err := g.waveGrid.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	labels := make([]savedLabel, len(g.labels))
+	for i, l := range g.labels {
+		labels[i] = savedLabel{X: l.x, Y: l.y, Text: l.text, Pin: slices.Index(g.waveGrid.Pins, l.pin), DX: l.dx, DY: l.dy}
+	}
+	if err := gob.NewEncoder(f).Encode(labels); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadState replaces the grid with the one in stateFile, which must have been
+// saved with the same grid dimensions as the running config, and the labels
+// with the ones saved after it, if any.
+func (g *Game) loadState() error {
+	f, err := os.Open(stateFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// The grid and the labels are separate gob streams, so both decoders
+	// must read through the same buffer
+	r := bufio.NewReader(f)
+	grid, err := wavegrid.Load(r)
+	if err != nil {
+		return err
+	}
+	width, height := grid.Size()
+	if width != g.cfg.gridWidth() || height != g.cfg.gridHeight() || grid.CellSize() != float64(g.cfg.GridSize) {
+		return fmt.Errorf("saved grid is %dx%d cells of %gpx, config is %dx%d of %dpx",
+			width, height, grid.CellSize(), g.cfg.gridWidth(), g.cfg.gridHeight(), g.cfg.GridSize)
+	}
+	var saved []savedLabel
+	if err := gob.NewDecoder(r).Decode(&saved); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading labels: %w", err)
+	}
+
+	// Keep how the field is displayed, which lives outside the grid
+	g.waveGrid.Grid = grid
+	if grid.Paddle != nil {
+		g.paddleFrequency, g.paddleAmplitude = grid.Paddle.Frequency, grid.Paddle.Amplitude
+	}
+	g.morphing = 0 // the morph outlines aren't saved
+	// Recorded clicks no longer lead to this field from a fresh pond
+	g.stepCount, g.events, g.replay = 0, nil, nil
+
+	g.labels, g.editing = g.labels[:0], -1
+	for _, s := range saved {
+		l := Label{x: s.X, y: s.Y, text: s.Text}
+		if s.Pin >= 0 && s.Pin < len(grid.Pins) {
+			l.pin, l.dx, l.dy = grid.Pins[s.Pin], s.DX, s.DY
+		}
+		g.labels = append(g.labels, l)
+	}
+	return nil
+}
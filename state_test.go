@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"game/wavegrid"
+)
+
+// TestSaveLoadLabels saves a pond with labels, one of them on a continuous
+// source, changes them, and loads it back. The labels must come back as
+// saved, the one on the source anchored to the loaded source.
+func TestSaveLoadLabels(t *testing.T) {
+	t.Chdir(t.TempDir())
+	g := newTestGame(t)
+	g.waveGrid.Pins = []*wavegrid.ContinuousSource{wavegrid.NewContinuousSource(100, 120, pinRadius, pinFrequency, pinAmplitude, g.timestep.dt)}
+	g.labels = []Label{g.newLabel(102, 121), {x: 300, y: 200, text: "node"}}
+	g.labels[0].text = "source A"
+	if err := g.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	g.labels = []Label{{x: 1, y: 2, text: "focus"}}
+	if err := g.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	pins := g.waveGrid.Pins
+	if len(pins) != 1 || pins[0].X != 100 || pins[0].Y != 120 {
+		t.Fatalf("loaded pins %v, want one at (100, 120)", pins)
+	}
+	want := []Label{{x: 102, y: 121, text: "source A", pin: pins[0], dx: 2, dy: 1}, {x: 300, y: 200, text: "node"}}
+	if len(g.labels) != len(want) {
+		t.Fatalf("loaded %d labels, want %d", len(g.labels), len(want))
+	}
+	for i, l := range g.labels {
+		if l != want[i] {
+			t.Errorf("label %d = %+v, want %+v", i, l, want[i])
+		}
+	}
+}
+
+// TestLoadStateWithoutLabels loads a state saved before labels were, which
+// holds the grid alone.
+func TestLoadStateWithoutLabels(t *testing.T) {
+	t.Chdir(t.TempDir())
+	g := newTestGame(t)
+	f, err := os.Create(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.waveGrid.Save(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	g.labels = []Label{{x: 1, y: 2, text: "focus"}}
+	if err := g.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.labels) != 0 {
+		t.Errorf("loaded %d labels, want none", len(g.labels))
+	}
+}
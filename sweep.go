@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"game/wavegrid"
+)
+
+// Settings of the resonance sweep run by -mode sweep: a small lossy circular
+// pond driven at one point, with the response measured at another.
+const (
+	sweepRadius   = 30.0  // cells
+	sweepSpeed    = 1.5   // cells per step, fast so the sweep covers many modes quickly
+	sweepDamping  = 0.995 // enough loss to settle within sweepWarmup steps
+	sweepWarmup   = 1200  // steps before measuring, about three decay times
+	sweepMeasure  = 400   // steps over which the amplitude is measured
+	sweepMinOmega = 0.04  // radians per step
+	sweepMaxOmega = 0.26
+	sweepStep     = 0.002
+)
+
+// besselZeros are the first zeros j(m,n) of the Bessel functions J_m, which
+// give the modes of a circular membrane held at its rim: ω = c·j(m,n)/R.
+var besselZeros = []struct {
+	m, n int
+	j    float64
+}{
+	{0, 1, 2.4048}, {1, 1, 3.8317}, {2, 1, 5.1356}, {0, 2, 5.5201},
+	{3, 1, 6.3802}, {1, 2, 7.0156}, {4, 1, 7.5883}, {2, 2, 8.4172},
+}
+
+// Resonance is a peak of the sweep's response curve.
+type Resonance struct {
+	omega     float64 // radians per step
+	amplitude float64 // steady-state amplitude at the probe
+}
+
+// sweepGrid returns a lossy, shoreless circular pond for the sweep.
+func sweepGrid() *WaveGrid {
+	cfg := DefaultConfig()
+	cfg.ScreenWidth, cfg.ScreenHeight = int(2*sweepRadius)+20, int(2*sweepRadius)+20
+	cfg.WaveSpeed = sweepSpeed
+	cfg.Damping = sweepDamping
+	cfg.ShoreWidth = 0
+	cx, cy := float64(cfg.ScreenWidth)/2, float64(cfg.ScreenHeight)/2
+	return NewWaveGrid(cfg, wavegrid.Circle(cx, cy, sweepRadius))
+}
+
+// steadyAmplitude drives a fresh sweep pond at omega radians per step and
+// returns the largest height seen at the probe once the response has settled.
+// Source and probe sit off center and off axis so most modes couple to both.
+func steadyAmplitude(omega float64) float64 {
+	wg := sweepGrid()
+	centerX, centerY := wg.Center()
+	cx, cy := int(centerX), int(centerY)
+	source := wg.Index(cx+int(sweepRadius/2), cy+int(sweepRadius/5))
+	px, py := cx-int(sweepRadius*2/5), cy+int(sweepRadius*3/10)
+
+	amplitude := 0.0
+	for step := range sweepWarmup + sweepMeasure {
+		wg.Velocities()[source] += math.Sin(omega * float64(step))
+		wg.Step()
+		if step >= sweepWarmup {
+			amplitude = math.Max(amplitude, math.Abs(wg.HeightAt(px, py)))
+		}
+	}
+	return amplitude
+}
+
+// SweepResonances drives the pond over the frequency range and returns the
+// peaks of the response, each refined by fitting a parabola through it and
+// its neighbors.
+func SweepResonances() []Resonance {
+	var omegas, amps []float64
+	for omega := sweepMinOmega; omega <= sweepMaxOmega; omega += sweepStep {
+		omegas = append(omegas, omega)
+		amps = append(amps, steadyAmplitude(omega))
+	}
+
+	var peaks []Resonance
+	for i := 1; i < len(amps)-1; i++ {
+		a, b, c := amps[i-1], amps[i], amps[i+1]
+		if b <= a || b < c {
+			continue
+		}
+		// Vertex of the parabola through the three samples
+		offset := 0.0
+		if d := a - 2*b + c; d != 0 {
+			offset = 0.5 * (a - c) / d
+		}
+		peaks = append(peaks, Resonance{omegas[i] + offset*sweepStep, b})
+	}
+	return peaks
+}
+
+// RunSweep prints the resonances of the sweep pond next to the nearest mode
+// of an ideal circular membrane. The grid's long waves travel at c·√(3/8)
+// cells per step, and the rim holds the first cell outside the mask still,
+// about half a cell beyond the mask edge.
+func RunSweep(stepTime float64) {
+	peaks := SweepResonances()
+	speed := sweepSpeed * math.Sqrt(3.0/8.0)
+	radius := sweepRadius + 0.5
+	fmt.Printf("%d resonances of a %.0f-cell circular pond between %.2f and %.2f rad/step:\n", len(peaks), sweepRadius, sweepMinOmega, sweepMaxOmega)
+	for _, p := range peaks {
+		best, bestErr := 0, math.Inf(1)
+		for i, z := range besselZeros {
+			if err := math.Abs(speed*z.j/radius - p.omega); err < bestErr {
+				best, bestErr = i, err
+			}
+		}
+		z := besselZeros[best]
+		predicted := speed * z.j / radius
+		fmt.Printf("  %.4f rad/step (%5.1f Hz)  amplitude %7.2f  nearest mode (%d,%d) at %.4f, off by %+.1f%%\n",
+			p.omega, p.omega/(2*math.Pi*stepTime), p.amplitude, z.m, z.n, predicted, 100*(p.omega-predicted)/predicted)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Theme is the set of colors drawn around the waves: the screen background,
+// the pond boundary and the ends of ThemeMap.
+type Theme struct {
+	name       string
+	Background color.RGBA
+	Boundary   color.RGBA
+	CrestHigh  color.RGBA
+	TroughHigh color.RGBA
+}
+
+// themes are the themes Shift+K cycles through, starting with the dark one.
+var themes = []Theme{
+	{
+		name:       "dark",
+		Background: color.RGBA{15, 20, 30, 255},
+		Boundary:   color.RGBA{100, 150, 200, 255},
+		CrestHigh:  color.RGBA{120, 200, 255, 255},
+		TroughHigh: color.RGBA{255, 140, 90, 255},
+	},
+	{
+		name:       "light",
+		Background: color.RGBA{240, 240, 235, 255},
+		Boundary:   color.RGBA{60, 80, 110, 255},
+		CrestHigh:  color.RGBA{20, 90, 200, 255},
+		TroughHigh: color.RGBA{200, 60, 30, 255},
+	},
+}
+
+// theme is the theme every mode draws with.
+var theme = themes[0]
+
+// updateTheme switches to the next theme on Shift+K, in any mode.
+func updateTheme() {
+	if !inpututil.IsKeyJustPressed(ebiten.KeyK) || !ebiten.IsKeyPressed(ebiten.KeyShift) {
+		return
+	}
+	for i, t := range themes {
+		if t.name == theme.name {
+			theme = themes[(i+1)%len(themes)]
+			return
+		}
+	}
+	theme = themes[0]
+}
+
+// ThemeMap fades from the theme background in still water to its crest and
+// trough colors, so the pond blends into the screen around it.
+type ThemeMap struct{}
+
+func (ThemeMap) At(norm float64) color.RGBA {
+	norm = math.Max(-1, math.Min(1, norm))
+	if norm > 0 {
+		return lerpColor(theme.Background, theme.CrestHigh, norm)
+	}
+	return lerpColor(theme.Background, theme.TroughHigh, -norm)
+}
+
+func (ThemeMap) String() string { return "theme" }
+
+// warningColor tints overlay text that needs attention, in every theme.
+var warningColor = color.RGBA{255, 80, 60, 255}
+
+// tintedText is the scratch image debugPrintTinted prints into.
+var tintedText *ebiten.Image
+
+// debugPrintTinted is ebitenutil.DebugPrintAt for a single line, in color c
+// instead of white.
+func debugPrintTinted(screen *ebiten.Image, str string, x, y int, c color.Color) {
+	width := screen.Bounds().Dx()
+	if tintedText == nil || tintedText.Bounds().Dx() != width {
+		tintedText = ebiten.NewImage(width, debugLineHeight)
+	}
+	tintedText.Clear()
+	ebitenutil.DebugPrint(tintedText, str)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	op.ColorScale.ScaleWithColor(c)
+	screen.DrawImage(tintedText, op)
+}
+
+// debugLineHeight is the height of a line of debug text, in pixels.
+const debugLineHeight = 16
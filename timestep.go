@@ -0,0 +1,35 @@
+package main
+
+import "time"
+
+// maxFrameTime caps how much real time a single Update may catch up on, so a
+// stall (window drag, breakpoint) doesn't trigger a burst of physics steps.
+const maxFrameTime = 0.25
+
+// fixedTimestep turns real elapsed time into a whole number of fixed-size
+// physics steps, so the simulation runs at the same speed on every machine
+// regardless of TPS or refresh rate.
+type fixedTimestep struct {
+	dt          float64 // seconds of real time per physics step
+	accumulator float64
+	last        time.Time
+}
+
+// steps returns how many physics steps are due since the previous call.
+func (f *fixedTimestep) steps() int {
+	now := time.Now()
+	if !f.last.IsZero() {
+		f.accumulator += min(now.Sub(f.last).Seconds(), maxFrameTime)
+	}
+	f.last = now
+
+	n := int(f.accumulator / f.dt)
+	f.accumulator -= float64(n) * f.dt
+	return n
+}
+
+// reset forgets the elapsed time, used while paused so resuming doesn't catch up.
+func (f *fixedTimestep) reset() {
+	f.accumulator = 0
+	f.last = time.Time{}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	tirWaveSpeed   = 1.0    // cells per step in the fast medium, so a packet crosses the slab in a few seconds
+	tirSlabHalf    = 120.0  // pixels from the middle of the slow slab to each interface
+	tirLauncherX   = -400.0 // pixels right of the middle of the pond
+	tirLauncherY   = 80.0   // pixels below the middle of the pond, so 40 above the lower interface
+	tirWavelength  = 16.0   // cells, in the slow medium
+	tirPacketWidth = 24.0   // cells, standard deviation of the envelope; wider packets spread over fewer angles
+	tirAmplitude   = 10.0
+
+	defaultTIRAngle = 20.0 // degrees from the normal to the interface
+	maxTIRAngle     = 75.0 // beyond it the packet runs off the side of the pond before reaching the interface
+	tirAngleStep    = 2.5
+)
+
+// TIR is the total internal reflection preset: a slab of slow water across
+// the pond with fast water above and below, and a launcher in the slab firing
+// wave packets at its upper interface. Below the critical angle most of a
+// packet crosses, bending away from the normal; past it the packet is
+// reflected whole, leaving only a faint evanescent wave on the far side.
+type TIR struct {
+	angle float64 // degrees between the launch direction and the normal to the interface
+}
+
+// startTIR sets up the pond for the total internal reflection preset and
+// fires the first packet, leaving label editing.
+func (g *Game) startTIR() {
+	g.labelMode, g.editing = false, -1
+	g.tir = &TIR{angle: defaultTIRAngle}
+
+	wg := g.waveGrid
+	wg.Pins, wg.Paddle, wg.Held = nil, nil, nil
+	wg.SetPeriodic(false)
+	wg.SetAnisotropy(1)
+	g.cfg.Periodic, g.cfg.Anisotropy = false, 1
+	wg.ClearObstacles()
+	g.setShape("box")
+	wg.Reflectivity = 0
+	wg.SetWaveSpeed(tirWaveSpeed)
+	g.cfg.WaveSpeed = wg.WaveSpeed()
+
+	width, _ := wg.Size()
+	cell := wg.CellSize()
+	_, cy := wg.Center()
+	wg.ClearSpeedRegions()
+	wg.PaintSpeedRect(0, cy-tirSlabHalf, float64(width)*cell, cy+tirSlabHalf, slowRegionFactor)
+
+	wg.ClearField()
+	g.replay = nil
+	g.paused = false
+	g.reversal.reset(wg.Grid)
+	g.launchTIRPacket()
+}
+
+// launchTIRPacket fires a packet from the launcher at the current angle.
+func (g *Game) launchTIRPacket() {
+	x, y := g.tirLauncher()
+	heading := -math.Pi/2 + g.tir.angle*math.Pi/180 // up and to the right
+	g.waveGrid.LaunchPacket(x, y, heading, tirWavelength, tirPacketWidth, tirAmplitude)
+}
+
+// tirLauncher returns the pixel position of the launcher.
+func (g *Game) tirLauncher() (x, y float64) {
+	cx, cy := g.waveGrid.Center()
+	return cx + tirLauncherX, cy + tirLauncherY
+}
+
+// criticalAngle returns the angle of incidence, in degrees, beyond which a
+// wave in the slow slab is totally reflected: where Snell's law would bend it
+// to 90° in the fast water, sin θc = c_slow / c_fast.
+func criticalAngle() float64 {
+	return math.Asin(slowRegionFactor) * 180 / math.Pi
+}
+
+// updateTIR turns the launcher with Left and Right, fires a packet on Enter
+// and ends the preset on Esc.
+func (g *Game) updateTIR() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.tir = nil
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		g.tir.angle = math.Min(maxTIRAngle, g.tir.angle+tirAngleStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		g.tir.angle = math.Max(0, g.tir.angle-tirAngleStep)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		g.launchTIRPacket()
+	}
+}
+
+// drawTIR draws the interfaces and the aim of the launcher up to the upper
+// interface, with the normal there, and prints the angles along the bottom.
+func (g *Game) drawTIR(screen *ebiten.Image) {
+	wg := g.waveGrid
+	width, _ := wg.Size()
+	right := float64(width) * wg.CellSize()
+	_, cy := wg.Center()
+	for _, y := range []float64{cy - tirSlabHalf, cy + tirSlabHalf} {
+		x1, y1 := g.gridToScreen(0, y)
+		x2, y2 := g.gridToScreen(right, y)
+		vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), 1, theme.Boundary, false)
+	}
+
+	// The aim runs from the launcher to the upper interface
+	lx, ly := g.tirLauncher()
+	theta := g.tir.angle * math.Pi / 180
+	hx, hy := lx+(ly-(cy-tirSlabHalf))*math.Tan(theta), cy-tirSlabHalf
+	aim := color.RGBA{255, 200, 80, 200}
+	x1, y1 := g.gridToScreen(lx, ly)
+	x2, y2 := g.gridToScreen(hx, hy)
+	vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), 1, aim, false)
+	vector.DrawFilledCircle(screen, float32(x1), float32(y1), 4, aim, false)
+	n1x, n1y := g.gridToScreen(hx, hy-tirSlabHalf/3)
+	n2x, n2y := g.gridToScreen(hx, hy+tirSlabHalf/3)
+	vector.StrokeLine(screen, float32(n1x), float32(n1y), float32(n2x), float32(n2y), 1, color.RGBA{200, 200, 200, 120}, false)
+
+	outcome := "partly crosses, bending away from the normal"
+	if g.tir.angle > criticalAngle() {
+		outcome = "totally reflected"
+	}
+	y := g.cfg.ScreenHeight - 2*debugLineHeight
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Total internal reflection: slow water inside fast, critical angle asin(c_slow/c_fast) = asin(%.2f/%.2f) = %.1f deg",
+		wg.WaveSpeed()*slowRegionFactor, wg.WaveSpeed(), criticalAngle()), 0, y)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Angle of incidence: %.1f deg (Left/Right), %s | Enter to launch a packet | Esc to exit", g.tir.angle, outcome), 0, y+debugLineHeight)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"game/wavegrid"
+)
+
+const (
+	tourPinSpacing = 60.0 // pixels between the two interfering sources
+	tourSlitWidth  = 12   // cells, about one paddle wavelength so the slit spreads the waves wide
+	tourPaddleGap  = 10   // cells between the paddle and the left edge of the pond
+)
+
+// tourStage is one scene of the guided tour: a pond set up by setup and left
+// to run for duration seconds under a caption.
+type tourStage struct {
+	caption      string
+	shape        string
+	reflectivity float64
+	duration     float64 // seconds before the tour moves on by itself
+	setup        func(g *Game)
+}
+
+// tourStages are the scenes the tour plays in order, looping back to the
+// first after the last.
+var tourStages = []tourStage{
+	{
+		caption:      "A single drop: the ring spreads out, bounces off the edge and refocuses in the middle",
+		shape:        "circle",
+		reflectivity: 1,
+		duration:     8,
+		setup: func(g *Game) {
+			cx, cy := g.waveGrid.Center()
+			g.waveGrid.AddWave(cx, cy, defaultBrushRadius)
+		},
+	},
+	{
+		caption:      "Two sources in step: their waves add up along some lines and cancel along others",
+		shape:        "circle",
+		reflectivity: 0,
+		duration:     12,
+		setup: func(g *Game) {
+			wg := g.waveGrid
+			cx, cy := wg.Center()
+			for _, x := range []float64{cx - tourPinSpacing/2, cx + tourPinSpacing/2} {
+				wg.Pins = append(wg.Pins, wavegrid.NewContinuousSource(x, cy, pinRadius, pinFrequency, pinAmplitude, g.timestep.dt))
+			}
+		},
+	},
+	{
+		caption:      "Diffraction: plane waves squeezing through a slit about a wavelength wide fan out behind it",
+		shape:        "rect",
+		reflectivity: 0,
+		duration:     15,
+		setup: func(g *Game) {
+			wg := g.waveGrid
+			cx, cy := wg.Center()
+			cell := wg.CellSize()
+			wallX, slitY := int(cx/cell), int(cy/cell)
+			_, height := wg.Size()
+			for y := range height {
+				if y < slitY-tourSlitWidth/2 || y > slitY+tourSlitWidth/2 {
+					wg.SetObstacle(wallX, y, 1)
+				}
+			}
+			left := int((cx - g.cfg.ShapeRadius) / cell)
+			wg.Paddle = wavegrid.NewPaddle(true, left+tourPaddleGap, defaultPaddleFrequency, defaultPaddleAmplitude, g.timestep.dt)
+		},
+	},
+}
+
+// Tour plays tourStages one after the other, for presentations. Each stage
+// starts from still water with nothing else in the pond, and gives way to the
+// next when its time is up or Enter is pressed.
+type Tour struct {
+	stage   int
+	started int // Game.frame the stage was set up on
+}
+
+// startTour begins the tour at its first stage, leaving label editing.
+func (g *Game) startTour() {
+	g.labelMode, g.editing = false, -1
+	g.tour = &Tour{}
+	g.setTourStage(0)
+}
+
+// setTourStage clears the pond and sets up stage i of the tour.
+func (g *Game) setTourStage(i int) {
+	stage := tourStages[i]
+	g.tour.stage, g.tour.started = i, g.frame
+
+	wg := g.waveGrid
+	wg.Pins, wg.Paddle, wg.Held = nil, nil, nil
+	wg.SetPeriodic(false)
+	wg.SetAnisotropy(1)
+	g.cfg.Periodic, g.cfg.Anisotropy = false, 1
+	wg.ClearObstacles()
+	g.setShape(stage.shape)
+	wg.Reflectivity = stage.reflectivity
+	wg.ClearField()
+	g.replay = nil
+	g.paused = false
+	g.reversal.reset(wg.Grid)
+	stage.setup(g)
+}
+
+// updateTour advances the tour on Enter or when the current stage has run
+// its course, and ends it on Esc.
+func (g *Game) updateTour() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.tour = nil
+		return
+	}
+	stage := tourStages[g.tour.stage]
+	elapsed := float64(g.frame-g.tour.started) / 60
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || elapsed >= stage.duration {
+		g.setTourStage((g.tour.stage + 1) % len(tourStages))
+	}
+}
+
+// drawTour prints the caption of the current stage along the bottom.
+func (g *Game) drawTour(screen *ebiten.Image) {
+	stage := tourStages[g.tour.stage]
+	elapsed := float64(g.frame-g.tour.started) / 60
+	y := g.cfg.ScreenHeight - 2*debugLineHeight
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Tour %d/%d: %s", g.tour.stage+1, len(tourStages), stage.caption), 0, y)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Next in %.0fs | Enter to skip ahead | Esc to exit the tour", stage.duration-elapsed), 0, y+debugLineHeight)
+}
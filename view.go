@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	minViewScale = 0.5
+	maxViewScale = 16.0
+	viewZoomStep = 1.1 // scale factor per wheel notch
+	viewPanSpeed = 8.0 // screen pixels per frame while Ctrl+arrow is held
+)
+
+// view is the transform from grid pixels to the screen: a grid position p is
+// drawn at offset + p·scale.
+type view struct {
+	scale            float64
+	offsetX, offsetY float64
+	dragging         bool
+	dragX, dragY     int // cursor position at the last frame of a drag
+}
+
+// centeredView returns the view of the given scale that keeps the grid point
+// (cx, cy) where it would be unzoomed, the view the demo starts with.
+func centeredView(scale, cx, cy float64) view {
+	return view{scale: scale, offsetX: (1 - scale) * cx, offsetY: (1 - scale) * cy}
+}
+
+func (v *view) toWorld(x, y float64) (float64, float64) {
+	return (x - v.offsetX) / v.scale, (y - v.offsetY) / v.scale
+}
+
+func (v *view) toScreen(x, y float64) (float64, float64) {
+	return v.offsetX + x*v.scale, v.offsetY + y*v.scale
+}
+
+// zoomAt scales the view by factor around the screen position (x, y), so the
+// grid point under it stays put.
+func (v *view) zoomAt(x, y, factor float64) {
+	wx, wy := v.toWorld(x, y)
+	v.scale = math.Max(minViewScale, math.Min(maxViewScale, v.scale*factor))
+	v.offsetX, v.offsetY = x-wx*v.scale, y-wy*v.scale
+}
+
+// update zooms at the cursor with the wheel and pans with a middle-button drag
+// or Ctrl+arrows. It leaves Ctrl+wheel and plain arrows to the caller.
+func (v *view) update() {
+	mx, my := ebiten.CursorPosition()
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl)
+	if _, wheelY := ebiten.Wheel(); wheelY != 0 && !ctrl {
+		v.zoomAt(float64(mx), float64(my), math.Pow(viewZoomStep, wheelY))
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonMiddle) {
+		v.dragging, v.dragX, v.dragY = true, mx, my
+	}
+	if v.dragging {
+		if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+			v.dragging = false
+		} else {
+			v.offsetX += float64(mx - v.dragX)
+			v.offsetY += float64(my - v.dragY)
+			v.dragX, v.dragY = mx, my
+		}
+	}
+
+	if ctrl {
+		if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+			v.offsetX += viewPanSpeed
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyRight) {
+			v.offsetX -= viewPanSpeed
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyUp) {
+			v.offsetY += viewPanSpeed
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyDown) {
+			v.offsetY -= viewPanSpeed
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package wavegrid
+
+import "math"
+
+// ContinuousSource is a pin dipping in and out of the water at a fixed
+// frequency, like the vibrating point source of a ripple tank. Unlike a click
+// it keeps going, so the pond fills with sustained and standing waves.
+type ContinuousSource struct {
+	X, Y      float64 // pixels
+	Radius    float64 // cells disturbed, as for AddWave
+	Frequency float64 // Hz of simulated time
+	Amplitude float64 // velocity added per step at the peak of the cycle
+	dt        float64 // simulated seconds per step
+	t         float64 // simulated seconds since the source started
+}
+
+func NewContinuousSource(x, y, radius, frequency, amplitude, dt float64) *ContinuousSource {
+	return &ContinuousSource{X: x, Y: y, Radius: radius, Frequency: frequency, Amplitude: amplitude, dt: dt}
+}
+
+// force adds this step's push to the velocities around the source and
+// advances its clock by one step. Step calls it first, so the push moves the
+// heights in the same step.
+func (s *ContinuousSource) force(wg *Grid) {
+	wg.AddImpulse(s.X, s.Y, s.Radius, s.Amplitude*math.Sin(2*math.Pi*s.Frequency*s.t))
+	s.t += s.dt
+}
@@ -0,0 +1,115 @@
+package wavegrid
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Settings of TestSquareModes.
+const (
+	eigenSquareSide = 100  // pixels
+	eigenPeriods    = 3    // periods measured
+	eigenTolerance  = 0.02 // largest accepted relative frequency error
+)
+
+// TestSquareModes seeds the lowest modes of a square pond, measures how fast
+// each oscillates and compares it with the analytic frequency, which it must
+// match within eigenTolerance.
+func TestSquareModes(t *testing.T) {
+	for _, mode := range [][2]int{{1, 1}, {2, 1}, {2, 2}, {3, 1}} {
+		t.Run(fmt.Sprintf("%d,%d", mode[0], mode[1]), func(t *testing.T) {
+			wg := squareModeGrid()
+			kx, ky, ax, ay := seedSquareMode(wg, mode[0], mode[1])
+			continuous, discrete := squareModeFrequencies(wg.WaveSpeed(), kx, ky)
+			maxSteps := int(4 * math.Pi / continuous * eigenPeriods)
+			measured := measureFrequency(wg, ax, ay, eigenPeriods, maxSteps)
+
+			relErr := math.Abs(measured-continuous) / continuous
+			if relErr > eigenTolerance {
+				t.Errorf("measured %.5f rad/step, analytic %.5f (error %.2f%%), discrete scheme %.5f",
+					measured, continuous, 100*relErr, discrete)
+			}
+		})
+	}
+}
+
+// squareModeGrid returns a small grid holding a single lossless square pond,
+// for checking the solver against the analytic modes of a square membrane.
+func squareModeGrid() *Grid {
+	const size = eigenSquareSide + 20
+	return New(testParams(size, size), Rect(size/2, size/2, eigenSquareSide, eigenSquareSide))
+}
+
+// seedSquareMode sets the (m, n) standing wave sin(kx·x)·sin(ky·y) at rest in
+// the square pond of wg. The reflecting boundary holds the first cell outside
+// the mask at zero, so that is where the sines are anchored. It returns the
+// wavenumbers in radians per cell and an antinode to watch.
+func seedSquareMode(wg *Grid, m, n int) (kx, ky float64, ax, ay int) {
+	width, height := wg.Size()
+	// Extent of the pond along the middle row and column
+	x0, x1 := width, -1
+	y0, y1 := height, -1
+	for x := 0; x < width; x++ {
+		if wg.InMask(x, height/2) {
+			x0, x1 = min(x0, x), max(x1, x)
+		}
+	}
+	for y := 0; y < height; y++ {
+		if wg.InMask(width/2, y) {
+			y0, y1 = min(y0, y), max(y1, y)
+		}
+	}
+
+	kx = float64(m) * math.Pi / float64(x1-x0+2)
+	ky = float64(n) * math.Pi / float64(y1-y0+2)
+	wg.ClearField()
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if wg.InMask(x, y) {
+				wg.height[wg.Index(x, y)] = math.Sin(kx*float64(x-x0+1)) * math.Sin(ky*float64(y-y0+1))
+			}
+		}
+	}
+
+	// The first antinode is half a wavelength in from the corner
+	ax = x0 - 1 + int(math.Round(math.Pi/(2*kx)))
+	ay = y0 - 1 + int(math.Round(math.Pi/(2*ky)))
+	return kx, ky, ax, ay
+}
+
+// measureFrequency steps wg until the height at (x, y) has completed periods
+// oscillations, or maxSteps pass, and returns its angular frequency in radians
+// per step from the zero crossings.
+func measureFrequency(wg *Grid, x, y, periods, maxSteps int) float64 {
+	var crossings []float64
+	prev := wg.HeightAt(x, y)
+	for step := 1; step <= maxSteps && len(crossings) < 2*periods+1; step++ {
+		wg.Step()
+		h := wg.HeightAt(x, y)
+		if (prev < 0) != (h < 0) {
+			// Interpolate where between the two steps the sign changed
+			crossings = append(crossings, float64(step-1)+prev/(prev-h))
+		}
+		prev = h
+	}
+	if len(crossings) < 2 {
+		return 0
+	}
+	halfPeriod := (crossings[len(crossings)-1] - crossings[0]) / float64(len(crossings)-1)
+	return math.Pi / halfPeriod
+}
+
+// squareModeFrequencies returns the angular frequency of a mode with
+// wavenumbers kx, ky for the continuous wave equation, and for Step's discrete
+// scheme. Step's averaged 8-neighbor Laplacian approaches 3/8 of the true
+// Laplacian for long waves, so its wave speed is c·√(3/8) cells per step.
+func squareModeFrequencies(c, kx, ky float64) (continuous, discrete float64) {
+	continuous = c * math.Sqrt(3.0/8.0) * math.Hypot(kx, ky)
+
+	// Eigenvalue of the averaged Laplacian, and the symplectic Euler update
+	a, b := math.Cos(kx), math.Cos(ky)
+	lambda := (8 - 2*a - 2*b - 4*a*b) / 8
+	discrete = math.Acos(1 - c*c*lambda/2)
+	return continuous, discrete
+}
@@ -0,0 +1,71 @@
+package wavegrid
+
+import "math"
+
+// energyTerms returns the parts of the energy of cell i at (x, y), see
+// Energy: v·v, h·Kh and v·Kh.
+func (wg *Grid) energyTerms(x, y, i int) (vv, hkh, vkh float64) {
+	h, v := wg.height[i], wg.velocity[i]
+	c := wg.waveSpeed * wg.speedFactor[i]
+	kh := -c * c * wg.laplacian(x, y, i)
+	return v * v, h * kh, v * kh
+}
+
+// energySums returns the energy terms summed over the pond.
+func (wg *Grid) energySums() (vv, hkh, vkh float64) {
+	for y := 0; y < wg.gridHeight; y++ {
+		for x := 0; x < wg.gridWidth; x++ {
+			i := wg.Index(x, y)
+			if !wg.mask[i] {
+				continue
+			}
+			a, b, c := wg.energyTerms(x, y, i)
+			vv, hkh, vkh = vv+a, hkh+b, vkh+c
+		}
+	}
+	return vv, hkh, vkh
+}
+
+// trackEnergy takes a step, measuring how much it changed the energy and
+// adding that to the drift as a fraction of the energy before. With
+// ConserveEnergy set, it then scales the velocities back to the energy before
+// the step.
+func (wg *Grid) trackEnergy() {
+	before := wg.Energy()
+	wg.step()
+	vv, hkh, vkh := wg.energySums()
+	after := (vv + hkh + vkh) / 2
+
+	if wg.ConserveEnergy && before > 0 {
+		// The energy is ½(s²·v·v + h·Kh + s·v·Kh) with the velocities
+		// scaled by s, so s is the root of a quadratic, taking the one
+		// near 1. No real root means the velocities alone can't make up
+		// the change, and the step is left as it is
+		a, b, c := vv, vkh, hkh-2*before
+		if disc := b*b - 4*a*c; a > 0 && disc >= 0 {
+			s := (-b + math.Sqrt(disc)) / (2 * a)
+			for i, inside := range wg.mask {
+				if inside {
+					wg.velocity[i] *= s
+				}
+			}
+			after = wg.Energy()
+		}
+	}
+	if before > 0 {
+		wg.energyDrift += (after - before) / before
+	}
+}
+
+// EnergyDrift returns how much the energy changed over the steps since
+// ResetEnergyDrift, as the sum of each step's change relative to the energy
+// before it. Only the solver counts, not clicks or other changes between
+// steps. It is measured while TrackEnergy or ConserveEnergy is set.
+func (wg *Grid) EnergyDrift() float64 {
+	return wg.energyDrift
+}
+
+// ResetEnergyDrift starts measuring EnergyDrift afresh.
+func (wg *Grid) ResetEnergyDrift() {
+	wg.energyDrift = 0
+}
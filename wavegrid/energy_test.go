@@ -0,0 +1,32 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEnergyThermostat runs the same pulse through a pond with a slow region,
+// the one place where Energy isn't held by the update itself, with and
+// without ConserveEnergy. Left alone the energy drifts; held, it must stay to
+// rounding error.
+func TestEnergyThermostat(t *testing.T) {
+	const steps, tolerance = 300, 1e-9
+	var drift [2]float64
+	for j, conserve := range []bool{false, true} {
+		wg := New(testParams(200, 200), Circle(100, 100, 80))
+		wg.PaintSpeedCircle(120, 100, 20, 0.5)
+		wg.AddWave(80, 100, testBrush)
+		wg.TrackEnergy, wg.ConserveEnergy = true, conserve
+		for range steps {
+			wg.Step()
+		}
+		drift[j] = wg.EnergyDrift()
+		t.Logf("ConserveEnergy %v: drift %.2e", conserve, drift[j])
+	}
+	if math.Abs(drift[0]) < 1e-3 {
+		t.Errorf("energy drifted by only %.2e without ConserveEnergy, too little for the check to show anything", drift[0])
+	}
+	if math.Abs(drift[1]) > tolerance {
+		t.Errorf("energy drifted by %.2e over %d steps with ConserveEnergy set (%.2e without), want at most %g", drift[1], steps, drift[0], tolerance)
+	}
+}
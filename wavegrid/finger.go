@@ -0,0 +1,52 @@
+package wavegrid
+
+import "math"
+
+// HoldFinger fills held with the cells under a finger of radius cells pressed
+// into the water at the pixel position (mx, my), each mapped to the height it
+// is pinned at: depth under the center, easing to zero at the rim. Negative
+// depths push the water down, positive ones pull it up. held is cleared first
+// and returned, ready to set as Held. Radii under a cell are taken as one
+// cell.
+func (wg *Grid) HoldFinger(mx, my, radius, depth float64, held map[int]float64) map[int]float64 {
+	radius = math.Max(1, radius)
+	if held == nil {
+		held = make(map[int]float64)
+	}
+	clear(held)
+
+	gridX := int(mx / wg.gridSize)
+	gridY := int(my / wg.gridSize)
+
+	// As for AddImpulse, a finger only dents the pond it is pressed into
+	fingerRegion := -1
+	if gridX >= 0 && gridX < wg.gridWidth && gridY >= 0 && gridY < wg.gridHeight {
+		fingerRegion = wg.region[wg.Index(gridX, gridY)]
+	}
+
+	for dy := -int(radius); dy <= int(radius); dy++ {
+		for dx := -int(radius); dx <= int(radius); dx++ {
+			x := gridX + dx
+			y := gridY + dy
+			if !wg.InMask(x, y) || fingerRegion >= 0 && wg.region[wg.Index(x, y)] != fingerRegion {
+				continue
+			}
+			if r := math.Hypot(float64(dx), float64(dy)) / radius; r <= 1 {
+				// A raised cosine, so the dent has no kink at its rim
+				held[wg.Index(x, y)] = depth * 0.5 * (1 + math.Cos(math.Pi*r))
+			}
+		}
+	}
+	return held
+}
+
+// hold pins the Held cells to their heights at rest. Step calls it after
+// moving the heights, like the paddle, so the water around feels the finger;
+// once a cell is let go it springs back from where it was held.
+func (wg *Grid) hold() {
+	for i, h := range wg.Held {
+		if wg.mask[i] {
+			wg.height[i], wg.velocity[i] = h, 0
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package wavegrid
+
+// Flux is the mean energy flux over a block of cells, placed at the block
+// center in pixels.
+type Flux struct {
+	X, Y   float64
+	FX, FY float64
+}
+
+// EnergyFlux averages the energy flux of the wave equation, -c²·v·∇h, over
+// blocks of block×block cells inside the pond, appending one arrow per block
+// to out. It points the way the waves carry energy, so incident and reflected
+// waves show as arrows in opposite directions, and standing waves as none.
+func (wg *Grid) EnergyFlux(block int, out []Flux) []Flux {
+	for by := 0; by+block <= wg.gridHeight; by += block {
+		for bx := 0; bx+block <= wg.gridWidth; bx += block {
+			var fx, fy float64
+			cells := 0
+			for y := by; y < by+block; y++ {
+				for x := bx; x < bx+block; x++ {
+					i := wg.Index(x, y)
+					if !wg.mask[i] {
+						continue
+					}
+					c := wg.waveSpeed * wg.speedFactor[i]
+					dhdx := (wg.HeightAt(x+1, y) - wg.HeightAt(x-1, y)) / 2
+					dhdy := (wg.HeightAt(x, y+1) - wg.HeightAt(x, y-1)) / 2
+					fx -= c * c * wg.velocity[i] * dhdx
+					fy -= c * c * wg.velocity[i] * dhdy
+					cells++
+				}
+			}
+			if cells > 0 {
+				center := (float64(block) - 1) / 2
+				out = append(out, Flux{
+					X:  (float64(bx) + center) * wg.gridSize,
+					Y:  (float64(by) + center) * wg.gridSize,
+					FX: fx / float64(cells),
+					FY: fy / float64(cells),
+				})
+			}
+		}
+	}
+	return out
+}
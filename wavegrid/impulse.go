@@ -0,0 +1,60 @@
+package wavegrid
+
+import "math"
+
+// ImpulseStrength is the velocity added at the center of an impulse.
+const ImpulseStrength = 40.0
+
+// sincLobes is how many half-periods of the sinc fit in the brush radius.
+const sincLobes = 3
+
+// ImpulseShape is the radial velocity profile AddWave injects over the brush.
+// The profile sets the spectral content of the disturbance: the grid stencil
+// makes short wavelengths travel slower than long ones, so shapes with more
+// energy near the grid scale smear into a trailing wash of ripples.
+type ImpulseShape int
+
+const (
+	Quadratic   ImpulseShape = iota // (1 - r)², a smooth blob with a soft kink at the edge
+	Gaussian                        // broad low-pass spectrum, a clean but long swell
+	Sinc                            // nearly flat spectrum up to a cutoff, see ImpulseProfile
+	SingleCycle                     // one wavelength of cosine, a narrow band around the brush size
+)
+
+func (s ImpulseShape) String() string {
+	switch s {
+	case Gaussian:
+		return "gaussian"
+	case Sinc:
+		return "sinc"
+	case SingleCycle:
+		return "single cycle"
+	}
+	return "quadratic"
+}
+
+// ImpulseProfile returns the relative strength of shape at r, the distance from
+// the center as a fraction of the brush radius, in [0, 1].
+//
+// The sinc is the Fourier transform of a box, so its spectrum is close to flat
+// up to a cutoff wavenumber set by sincLobes and nearly empty above it. No
+// energy goes into the grid-scale wavelengths the stencil disperses most, and
+// the wavelengths it does carry move at nearly the same speed, so the ring
+// stays a sharp front with ripples at the cutoff wavelength behind it.
+func ImpulseProfile(shape ImpulseShape, r float64) float64 {
+	// Hann window, taking the oscillating shapes smoothly to zero at the edge
+	window := 0.5 * (1 + math.Cos(math.Pi*r))
+	switch shape {
+	case Gaussian:
+		return math.Exp(-4.5 * r * r) // σ is a third of the radius
+	case Sinc:
+		if r == 0 {
+			return 1
+		}
+		x := sincLobes * math.Pi * r
+		return math.Sin(x) / x * window
+	case SingleCycle:
+		return math.Cos(2*math.Pi*r) * window
+	}
+	return (1 - r) * (1 - r)
+}
@@ -0,0 +1,65 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// TestImpulseEnergy clicks a still pond once with each impulse shape. The
+// water is flat, so the energy is ½Σv², which for a brush of radius R cells
+// comes close to the integral of ½(strength·profile)² over the disc,
+// ½·strength²·2πR²·∫p(r)²r dr.
+func TestImpulseEnergy(t *testing.T) {
+	const radius, strength = 20.0, 3.0
+	for shape := Quadratic; shape <= SingleCycle; shape++ {
+		wg := New(testParams(200, 200), Circle(100, 100, 80))
+		wg.Impulse = shape
+		wg.AddImpulse(100, 100, radius, strength)
+
+		const n = 10000
+		integral := 0.0
+		for i := range n {
+			r := (float64(i) + 0.5) / n
+			p := ImpulseProfile(shape, r)
+			integral += p * p * r / n
+		}
+		want := 0.5 * strength * strength * 2 * math.Pi * radius * radius * integral
+		if got := wg.Energy(); math.Abs(got-want) > 0.02*want {
+			t.Errorf("%v: energy %g, want %g within 2%%", shape, got, want)
+		}
+	}
+}
+
+// TestSubpixelImpulse clicks at fractions of a cell around the middle of a
+// pond of 4-pixel cells and checks that the bilinear weights AddWave spreads
+// each click with add up to the velocity a click right on a cell injects, and
+// that the injected velocity is centered on the click.
+func TestSubpixelImpulse(t *testing.T) {
+	const tolerance = 1e-9 // relative to the total, and in cells
+	p := testParams(1000, 600)
+	p.GridSize = 4
+
+	// total returns the velocity a click at (x, y) injects, and its center in cells
+	total := func(x, y float64) (sum, centerX, centerY float64) {
+		wg := New(p, Circle(500, 300, 150))
+		wg.AddWave(x, y, testBrush)
+		width, _ := wg.Size()
+		for i, v := range wg.Velocities() {
+			sum += v
+			centerX += v * float64(i%width)
+			centerY += v * float64(i/width)
+		}
+		return sum, centerX / sum, centerY / sum
+	}
+
+	want, _, _ := total(500, 300)
+	for _, offset := range [][2]float64{{1, 0}, {0, 2.5}, {1.3, 3.7}, {3.99, 0.01}} {
+		x, y := 500+offset[0], 300+offset[1]
+		sum, centerX, centerY := total(x, y)
+		relErr := math.Abs(sum-want) / want
+		offCenter := math.Hypot(centerX-x/float64(p.GridSize), centerY-y/float64(p.GridSize))
+		if relErr > tolerance || offCenter > tolerance {
+			t.Errorf("click at (%.2f, %.2f) injected %.6f instead of %.6f, centered %.2e cells off", x, y, sum, want, offCenter)
+		}
+	}
+}
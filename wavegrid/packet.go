@@ -0,0 +1,32 @@
+package wavegrid
+
+import "math"
+
+// LaunchPacket adds a wave packet centered on the pixel position (px, py) and
+// heading at angle radians from the x axis, with y pointing down: crests
+// wavelength cells apart under a round Gaussian envelope whose standard
+// deviation is width cells. The velocity is set for a wave moving along the
+// heading at the local speed, so the packet sets off in one direction instead
+// of splitting in two like a drop from rest.
+func (wg *Grid) LaunchPacket(px, py, angle, wavelength, width, amplitude float64) {
+	cx, cy := px/wg.gridSize, py/wg.gridSize
+	nx, ny := math.Cos(angle), math.Sin(angle)
+	k := 2 * math.Pi / wavelength
+	reach := 4 * width // the envelope is below 0.04% of its peak beyond
+	for y := max(0, int(cy-reach)); y <= min(wg.gridHeight-1, int(cy+reach)); y++ {
+		for x := max(0, int(cx-reach)); x <= min(wg.gridWidth-1, int(cx+reach)); x++ {
+			i := wg.Index(x, y)
+			if !wg.mask[i] {
+				continue
+			}
+			dx, dy := float64(x)-cx, float64(y)-cy
+			along := dx*nx + dy*ny
+			envelope := amplitude * math.Exp(-(dx*dx+dy*dy)/(2*width*width))
+			// A profile h(r - v·t·n) moving along n has dh/dt = -v·n·∇h, with
+			// waves travelling at c·√(3/8) cells per step on every stencil
+			v := wg.waveSpeed * wg.speedFactor[i] * math.Sqrt(3.0/8.0)
+			wg.height[i] += envelope * math.Cos(k*along)
+			wg.velocity[i] += v * envelope * (k*math.Sin(k*along) + along/(width*width)*math.Cos(k*along))
+		}
+	}
+}
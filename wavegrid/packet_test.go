@@ -0,0 +1,86 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// energyRows returns the energy of the pond cells in rows y0 up to but not
+// including y1: the terms of Energy over the squared local speed, which is
+// what a wave keeps crossing into a medium of another speed.
+func energyRows(wg *Grid, y0, y1 int) float64 {
+	e := 0.0
+	for y := y0; y < y1; y++ {
+		for x := 0; x < wg.gridWidth; x++ {
+			if i := wg.Index(x, y); wg.mask[i] {
+				vv, hkh, vkh := wg.energyTerms(x, y, i)
+				c := wg.waveSpeed * wg.speedFactor[i]
+				e += (vv + hkh + vkh) / (2 * c * c)
+			}
+		}
+	}
+	return e
+}
+
+// TestLaunchPacket sends a packet down an absorbing channel. It must travel
+// the way it was aimed, leaving almost nothing behind.
+func TestLaunchPacket(t *testing.T) {
+	p := testParams(400, 100)
+	p.Reflectivity = 0
+	wg := New(p, Rect(200, 50, 380, 80))
+	wg.LaunchPacket(100, 50, math.Pi, 16, 12, 10) // heading left, toward x = 0
+	for range 200 {
+		wg.Step()
+	}
+
+	var left, right, sum float64
+	for y := range 100 {
+		for x := range 400 {
+			h := wg.HeightAt(x, y)
+			sum += h * h
+			if x < 100 {
+				left += h * h
+			} else {
+				right += h * h
+			}
+		}
+	}
+	if right > 0.02*sum {
+		t.Errorf("%.1f%% of the packet went right, the wrong way", 100*right/sum)
+	}
+	if left < 0.9*sum {
+		t.Errorf("only %.1f%% of the packet went left", 100*left/sum)
+	}
+}
+
+// TestTotalInternalReflection aims packets from a slow medium at the interface
+// with one twice as fast, where the critical angle is 30°. Below it most of
+// the packet gets through; well beyond it all of it is reflected.
+func TestTotalInternalReflection(t *testing.T) {
+	const interfaceY, distance = 120, 70 // the launcher is distance cells below the interface
+	for _, c := range []struct {
+		degrees                float64
+		minThrough, maxThrough float64 // share of the energy crossing the interface
+	}{
+		{15, 0.5, 1},
+		{50, 0, 0.02},
+	} {
+		p := testParams(280, 240)
+		p.WaveSpeed, p.Reflectivity = 1, 0
+		wg := New(p, Rect(140, 120, 260, 220))
+		wg.PaintSpeedRect(0, interfaceY, 280, 240, 0.5)
+		theta := c.degrees * math.Pi / 180
+		wg.LaunchPacket(60, interfaceY+distance, -math.Pi/2+theta, 16, 24, 10)
+		total := energyRows(wg, 0, 240)
+
+		// Until the middle of the packet is well past the interface
+		slow := 0.5 * math.Sqrt(3.0/8.0)
+		for range int((distance/math.Cos(theta) + 45) / slow) {
+			wg.Step()
+		}
+		if through := energyRows(wg, 0, interfaceY) / total; through < c.minThrough || through > c.maxThrough {
+			t.Errorf("at %g°, %.1f%% of the energy crossed the interface, want %g%% to %g%%",
+				c.degrees, 100*through, 100*c.minThrough, 100*c.maxThrough)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package wavegrid
+
+import "math"
+
+// Paddle drives a whole grid row or column up and down like the bar of a
+// ripple tank, sending out straight wavefronts instead of circular ones.
+type Paddle struct {
+	Vertical  bool    // drives a column, so the fronts travel sideways; otherwise a row
+	Line      int     // index of the driven column or row
+	Frequency float64 // Hz of simulated time
+	Amplitude float64 // height the paddle swings to either side
+	dt        float64 // simulated seconds per step
+	t         float64 // simulated seconds since the paddle started
+}
+
+func NewPaddle(vertical bool, line int, frequency, amplitude, dt float64) *Paddle {
+	return &Paddle{Vertical: vertical, Line: line, Frequency: frequency, Amplitude: amplitude, dt: dt}
+}
+
+// drive sets every pond cell on the paddle's line to the paddle height and
+// advances its clock by one step. Step calls it after moving the heights, so
+// the neighbors feel the paddle when their velocities are computed.
+func (p *Paddle) drive(wg *Grid) {
+	h := p.Amplitude * math.Sin(2*math.Pi*p.Frequency*p.t)
+	p.t += p.dt
+
+	n := wg.gridWidth
+	if p.Vertical {
+		n = wg.gridHeight
+	}
+	for j := range n {
+		x, y := j, p.Line
+		if p.Vertical {
+			x, y = p.Line, j
+		}
+		if wg.InMask(x, y) {
+			i := wg.Index(x, y)
+			wg.height[i] = h
+			wg.velocity[i] = 0
+		}
+	}
+}
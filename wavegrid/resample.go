@@ -0,0 +1,121 @@
+package wavegrid
+
+import (
+	"math"
+	"slices"
+)
+
+// Resample rebuilds wg on cells of newGridSize pixels, carrying the waves
+// over by bilinear interpolation so they keep going on the new grid. The
+// wave speed is rescaled to keep waves moving as many pixels per step, as
+// far as the stability limit allows, and the shore keeps its width in pixels.
+func (wg *Grid) Resample(newGridSize int) {
+	scale := wg.gridSize / float64(newGridSize) // new cells per old cell
+	p := Params{
+		Width:        int(2 * wg.cx),
+		Height:       int(2 * wg.cy),
+		GridSize:     newGridSize,
+		WaveSpeed:    wg.waveSpeed * scale,
+		Damping:      wg.damping,
+		ShoreWidth:   int(math.Round(float64(wg.shoreWidth) * scale)),
+		ShoreDamping: wg.shoreDamping,
+		Reflectivity: wg.Reflectivity,
+		Periodic:     wg.periodic,
+		Stencil:      wg.stencil,
+		Anisotropy:   wg.anisotropy,
+	}
+	next := New(p, wg.shapes...)
+	if wg.morphFrom != nil {
+		next.morphFrom, next.morphTo, next.morph = wg.morphFrom, wg.morphTo, wg.morph
+		next.rebuildMorphMask()
+	}
+
+	wg.carryOver(next, scale, 0, 0)
+}
+
+// Resize rebuilds wg for a screen of width×height pixels and the pond
+// outlines shapes laid out on it, keeping the cell size. Everything on the old
+// grid moves dx, dy pixels, so the waves stay where they were relative to the
+// ponds when the screen is recentered around them, carried over by bilinear
+// interpolation as the move needn't be a whole number of cells. Water that
+// ends up off the grid or on land is dropped, and a morph is dropped too.
+func (wg *Grid) Resize(width, height int, shapes []Shape, dx, dy float64) {
+	p := Params{
+		Width:        width,
+		Height:       height,
+		GridSize:     int(wg.gridSize),
+		WaveSpeed:    wg.waveSpeed,
+		Damping:      wg.damping,
+		ShoreWidth:   wg.shoreWidth,
+		ShoreDamping: wg.shoreDamping,
+		Reflectivity: wg.Reflectivity,
+		Periodic:     wg.periodic,
+		Stencil:      wg.stencil,
+		Anisotropy:   wg.anisotropy,
+	}
+	next := New(p, shapes...)
+	for _, pin := range wg.Pins {
+		pin.X, pin.Y = pin.X+dx, pin.Y+dy
+	}
+	wg.carryOver(next, 1, dx/wg.gridSize, dy/wg.gridSize)
+}
+
+// carryOver copies the field, the painted media and the drivers of wg onto
+// next, whose cells are scale times smaller, with everything moved ox, oy old
+// cells, and then replaces wg with it.
+func (wg *Grid) carryOver(next *Grid, scale, ox, oy float64) {
+	next.obstacleOff = slices.Clone(wg.obstacleOff)
+	for y := 0; y < next.gridHeight; y++ {
+		for x := 0; x < next.gridWidth; x++ {
+			// The old cell coordinates of this cell's sample point
+			sx, sy := float64(x)/scale-ox, float64(y)/scale-oy
+			cx, cy := int(math.Floor(sx)), int(math.Floor(sy))
+			if cx >= 0 && cy >= 0 && cx < wg.gridWidth && cy < wg.gridHeight {
+				old := wg.Index(cx, cy)
+				next.speedFactor[next.Index(x, y)] = wg.speedFactor[old]
+				if id := wg.obstacle[old]; id > 0 && next.mask[next.Index(x, y)] {
+					next.carveObstacle(next.Index(x, y), id)
+				}
+				if wg.interfaceCell[old] {
+					next.SetInterface(x, y, 0, true)
+				}
+			}
+
+			i := next.Index(x, y)
+			if next.mask[i] {
+				next.height[i] = wg.bilinear(wg.height, sx, sy)
+				next.velocity[i] = wg.bilinear(wg.velocity, sx, sy)
+			}
+		}
+	}
+
+	next.Transmission = wg.Transmission
+	next.Impulse = wg.Impulse
+	next.TrackEnergy, next.ConserveEnergy = wg.TrackEnergy, wg.ConserveEnergy
+	next.Pins = wg.Pins
+	if p := wg.Paddle; p != nil {
+		offset := oy
+		if p.Vertical {
+			offset = ox
+		}
+		p.Line = int(math.Floor((float64(p.Line) + 0.5 + offset) * scale))
+		next.Paddle = p
+	}
+	*wg = *next
+}
+
+// bilinear interpolates field between the four cells around (x, y), in cell
+// coordinates. Cells outside the mask count as still water.
+func (wg *Grid) bilinear(field []float64, x, y float64) float64 {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	tx, ty := x-float64(x0), y-float64(y0)
+	at := func(x, y int) float64 {
+		if !wg.InMask(x, y) {
+			return 0
+		}
+		return field[wg.Index(x, y)]
+	}
+	top := at(x0, y0)*(1-tx) + at(x0+1, y0)*tx
+	bottom := at(x0, y0+1)*(1-tx) + at(x0+1, y0+1)*tx
+	return top*(1-ty) + bottom*ty
+}
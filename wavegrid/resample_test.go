@@ -0,0 +1,47 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// TestResize spreads a click, resizes the screen the way a window resize does
+// in grid mode and checks that every cell of the new pond holds the field
+// interpolated from where it was before the pond moved to the new center:
+// exactly, when the move is a whole number of cells, and by bilinear
+// interpolation when it is half a pixel.
+func TestResize(t *testing.T) {
+	const width, height, tolerance = 1000, 600, 1e-9
+	grid := func() *Grid {
+		wg := New(testParams(width, height), Circle(width/2, height/2, 150))
+		wg.AddWave(width/2+20, height/2-10, testBrush)
+		for range 200 {
+			wg.Step()
+		}
+		return wg
+	}
+
+	for _, size := range [][2]int{{width + 200, height + 100}, {width + 1, height - 1}} {
+		before, after := grid(), grid()
+		cx, cy := float64(size[0])/2, float64(size[1])/2
+		dx, dy := cx-width/2, cy-height/2
+		after.Resize(size[0], size[1], []Shape{Circle(cx, cy, 150)}, dx, dy)
+
+		w, h := after.Size()
+		if w != size[0] || h != size[1] {
+			t.Fatalf("resized grid is %dx%d cells for a %dx%d screen", w, h, size[0], size[1])
+		}
+		worst := 0.0
+		for y := range h {
+			for x := range w {
+				if after.InMask(x, y) {
+					want := before.SampleHeight(float64(x)-dx, float64(y)-dy)
+					worst = math.Max(worst, math.Abs(after.HeightAt(x, y)-want))
+				}
+			}
+		}
+		if worst > tolerance {
+			t.Errorf("resizing to %dx%d moved the field wrongly, off by %.2e", size[0], size[1], worst)
+		}
+	}
+}
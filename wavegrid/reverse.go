@@ -0,0 +1,34 @@
+package wavegrid
+
+// StepBack undoes one Step, running the waves backward in time, so a wave
+// that scattered off the pond edge gathers back into the click that made it.
+// Step set v' = (v + c²·L(h'))·damping with h' = h + v, so from h' the old
+// velocity is v'/damping - c²·L(h'), and then the old height h' - v. In a
+// reflecting pond that is exact to rounding; absorbing edges are only undone
+// approximately, and drivers such as pins and the paddle are not undone.
+func (wg *Grid) StepBack() {
+	prevVelocity := wg.nextVelocity
+
+	border := 1
+	if wg.periodic {
+		border = 0
+	}
+	for y := border; y < wg.gridHeight-border; y++ {
+		for x := border; x < wg.gridWidth-border; x++ {
+			i := wg.Index(x, y)
+			if !wg.mask[i] {
+				prevVelocity[i] = 0
+				continue
+			}
+			c := wg.waveSpeed * wg.speedFactor[i]
+			prevVelocity[i] = wg.velocity[i]/(wg.damping*wg.dampingFactor[i]) - wg.laplacian(x, y, i)*c*c
+		}
+	}
+	wg.velocity, wg.nextVelocity = prevVelocity, wg.velocity
+
+	for i, inside := range wg.mask {
+		if inside {
+			wg.height[i] -= wg.velocity[i]
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package wavegrid
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+)
+
+// SeedFromImage replaces the field with the PNG at path, see SeedImage.
+func (wg *Grid) SeedFromImage(path string, amplitude float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return wg.SeedImage(img, amplitude)
+}
+
+// SeedImage replaces the field with still water shaped like img: each pond
+// cell is raised by amplitude times the luminance of the image under it, with
+// zero velocity, so the picture spreads out into waves from rest. The image is
+// scaled to fit the bounding box of the pond, keeping its aspect ratio, and
+// centered in it; pond cells it doesn't cover are left flat, and the parts
+// over land are cropped by the mask. Where a cell covers several pixels their
+// luminance is averaged.
+func (wg *Grid) SeedImage(img image.Image, amplitude float64) error {
+	b := img.Bounds()
+	if b.Empty() {
+		return fmt.Errorf("image is empty")
+	}
+
+	// Bounding box of the pond, in cells
+	x0, y0, x1, y1 := wg.gridWidth, wg.gridHeight, -1, -1
+	for y := 0; y < wg.gridHeight; y++ {
+		for x := 0; x < wg.gridWidth; x++ {
+			if wg.mask[wg.Index(x, y)] {
+				x0, y0 = min(x0, x), min(y0, y)
+				x1, y1 = max(x1, x), max(y1, y)
+			}
+		}
+	}
+	if x1 < 0 {
+		return fmt.Errorf("the grid has no pond to seed")
+	}
+
+	// Pixels per cell, and the cell the image's top-left corner lands on
+	boxWidth, boxHeight := float64(x1-x0+1), float64(y1-y0+1)
+	scale := math.Max(float64(b.Dx())/boxWidth, float64(b.Dy())/boxHeight)
+	left := float64(x0) + (boxWidth-float64(b.Dx())/scale)/2
+	top := float64(y0) + (boxHeight-float64(b.Dy())/scale)/2
+
+	wg.ClearField()
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			i := wg.Index(x, y)
+			if !wg.mask[i] {
+				continue
+			}
+			// Pixels under the cell, at least the one under its corner
+			px0 := b.Min.X + int(math.Floor((float64(x)-left)*scale))
+			py0 := b.Min.Y + int(math.Floor((float64(y)-top)*scale))
+			px1 := max(px0+1, b.Min.X+int(math.Floor((float64(x+1)-left)*scale)))
+			py1 := max(py0+1, b.Min.Y+int(math.Floor((float64(y+1)-top)*scale)))
+			px0, py0 = max(px0, b.Min.X), max(py0, b.Min.Y)
+			px1, py1 = min(px1, b.Max.X), min(py1, b.Max.Y)
+			if px0 >= px1 || py0 >= py1 {
+				continue
+			}
+
+			sum := 0.0
+			for py := py0; py < py1; py++ {
+				for px := px0; px < px1; px++ {
+					sum += float64(color.GrayModel.Convert(img.At(px, py)).(color.Gray).Y) / 255
+				}
+			}
+			wg.height[i] = amplitude * sum / float64((px1-px0)*(py1-py0))
+		}
+	}
+	return nil
+}
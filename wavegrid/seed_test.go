@@ -0,0 +1,56 @@
+package wavegrid
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// TestSeedImage seeds a pond 40×20 cells from a running wave and two
+// grayscale images, one with the pond's aspect ratio and a square one. Each
+// must be scaled to 5 cells a pixel and centered, every cell raised by the
+// luminance of the pixel over it and the rest left flat, with the water at
+// rest.
+func TestSeedImage(t *testing.T) {
+	const amplitude = 3.0
+	for _, c := range []struct {
+		width, height int
+		left          int // cells left of the image
+	}{
+		{8, 4, 0},
+		{4, 4, 10},
+	} {
+		img := image.NewGray(image.Rect(0, 0, c.width, c.height))
+		for i := range img.Pix {
+			img.Pix[i] = uint8(8 + 16*i)
+		}
+		wg := New(testParams(40, 20), Rect(20, 10, 42, 22))
+		wg.AddWave(20, 10, testBrush)
+		wg.Step()
+		if err := wg.SeedImage(img, amplitude); err != nil {
+			t.Fatal(err)
+		}
+
+		for y := range 20 {
+			for x := range 40 {
+				want := 0.0
+				if px := (x - c.left) / 5; x >= c.left && px < c.width {
+					want = amplitude * float64(img.GrayAt(px, y/5).Y) / 255
+				}
+				if h := wg.HeightAt(x, y); math.Abs(h-want) > 1e-12 {
+					t.Errorf("%dx%d image: height at (%d, %d) = %g, want %g", c.width, c.height, x, y, h, want)
+				}
+			}
+		}
+		for i, v := range wg.Velocities() {
+			if v != 0 {
+				t.Fatalf("%dx%d image: velocity of cell %d = %g, want the water at rest", c.width, c.height, i, v)
+			}
+		}
+	}
+
+	wg := New(testParams(40, 20), Rect(20, 10, 42, 22))
+	if err := wg.SeedImage(image.NewGray(image.Rectangle{}), amplitude); err == nil {
+		t.Error("seeding from an empty image succeeded, want an error")
+	}
+}
@@ -0,0 +1,129 @@
+package wavegrid
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// closeEnough is how near the last point must be to the first for an outline
+// to count as closed.
+const closeEnough = 1e-6
+
+var polylinePoints = regexp.MustCompile(`<polyline[^>]*\spoints\s*=\s*"([^"]*)"`)
+
+// LoadShape reads a pond outline, in pixels, from a file. Two formats are
+// accepted: one "x,y" pair per line (blank lines and lines starting with #
+// are skipped), or an SVG document whose first <polyline points="..."> holds
+// the outline. The outline must end where it starts and must not cross itself.
+func LoadShape(path string) ([]Vector2, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var shape []Vector2
+	if m := polylinePoints.FindSubmatch(data); m != nil {
+		shape, err = parsePoints(strings.Fields(strings.ReplaceAll(string(m[1]), ",", " ")), 2)
+	} else {
+		var fields []string
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			x, y, ok := strings.Cut(line, ",")
+			if !ok {
+				return nil, fmt.Errorf("%s: expected \"x,y\", got %q", path, line)
+			}
+			fields = append(fields, strings.TrimSpace(x), strings.TrimSpace(y))
+		}
+		shape, err = parsePoints(fields, 2)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := validatePolygon(shape); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// The closing point duplicates the first one
+	return shape[:len(shape)-1], nil
+}
+
+// parsePoints turns a flat list of coordinates into points.
+func parsePoints(fields []string, perPoint int) ([]Vector2, error) {
+	if len(fields)%perPoint != 0 {
+		return nil, fmt.Errorf("odd number of coordinates (%d)", len(fields))
+	}
+	shape := make([]Vector2, 0, len(fields)/perPoint)
+	for i := 0; i < len(fields); i += perPoint {
+		x, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad x coordinate %q", fields[i])
+		}
+		y, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad y coordinate %q", fields[i+1])
+		}
+		shape = append(shape, Vector2{x, y})
+	}
+	return shape, nil
+}
+
+// validatePolygon checks that the outline, whose last point repeats the first,
+// encloses an area and doesn't cross itself.
+func validatePolygon(shape []Vector2) error {
+	if len(shape) == 0 {
+		return fmt.Errorf("no points")
+	}
+	first, last := shape[0], shape[len(shape)-1]
+	if math.Abs(first.X-last.X) > closeEnough || math.Abs(first.Y-last.Y) > closeEnough {
+		return fmt.Errorf("outline is not closed: ends at (%g, %g) but starts at (%g, %g)", last.X, last.Y, first.X, first.Y)
+	}
+	if len(shape) < 4 {
+		return fmt.Errorf("need at least 3 distinct points, got %d", len(shape)-1)
+	}
+
+	// Edges sharing a vertex always touch, so only compare the others
+	edges := len(shape) - 1
+	for i := 0; i < edges; i++ {
+		for j := i + 2; j < edges; j++ {
+			if i == 0 && j == edges-1 {
+				continue
+			}
+			if segmentsIntersect(shape[i], shape[i+1], shape[j], shape[j+1]) {
+				return fmt.Errorf("outline crosses itself: edge %d intersects edge %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// segmentsIntersect reports whether segments ab and cd touch or cross.
+func segmentsIntersect(a, b, c, d Vector2) bool {
+	d1 := orientation(c, d, a)
+	d2 := orientation(c, d, b)
+	d3 := orientation(a, b, c)
+	d4 := orientation(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
+
+// orientation is the cross product of (b - a) and (p - a): positive when p is
+// to the left of ab, negative to the right, zero when collinear.
+func orientation(a, b, p Vector2) float64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// onSegment reports whether p, known to be collinear with ab, lies between a and b.
+func onSegment(a, b, p Vector2) bool {
+	return p.X >= math.Min(a.X, b.X) && p.X <= math.Max(a.X, b.X) &&
+		p.Y >= math.Min(a.Y, b.Y) && p.Y <= math.Max(a.Y, b.Y)
+}
@@ -0,0 +1,59 @@
+package wavegrid
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeShapeFile writes content to a file named name in a fresh directory and
+// returns its path.
+func writeShapeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadShapeSquare loads the same square from a point file and from an
+// SVG polyline, and builds a pond from it.
+func TestLoadShapeSquare(t *testing.T) {
+	want := []Vector2{{10, 10}, {90, 10}, {90, 90}, {10, 90}}
+	for _, c := range []struct{ name, content string }{
+		{"square.txt", "# a square\n10,10\n90, 10\n\n90,90\n10,90\n10,10\n"},
+		{"square.svg", `<svg><polyline fill="none" points="10,10 90,10 90,90 10,90 10,10"/></svg>`},
+	} {
+		shape, err := LoadShape(writeShapeFile(t, c.name, c.content))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !slices.Equal(shape, want) {
+			t.Errorf("%s: loaded %v, want %v", c.name, shape, want)
+		}
+		wg := New(testParams(100, 100), shape)
+		if !wg.InMask(50, 50) || wg.InMask(5, 50) || wg.InMask(95, 95) {
+			t.Errorf("%s: the pond doesn't cover the square", c.name)
+		}
+	}
+}
+
+// TestLoadShapeErrors checks that broken outlines are rejected with an error
+// saying what is wrong.
+func TestLoadShapeErrors(t *testing.T) {
+	for _, c := range []struct{ content, want string }{
+		{"10,10\n90,10\n90,90\n10,90\n", "not closed"},
+		{"10,10\n90,90\n90,10\n10,90\n10,10\n", "crosses itself"},
+		{"10,10\n90,10\n10,10\n", "at least 3"},
+		{"10,10\n90 10\n", `expected "x,y"`},
+		{"10,10\nninety,10\n", "bad x coordinate"},
+	} {
+		_, err := LoadShape(writeShapeFile(t, "shape.txt", c.content))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("loading %q: error %v, want one containing %q", c.content, err, c.want)
+		}
+	}
+}
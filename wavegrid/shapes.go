@@ -0,0 +1,198 @@
+package wavegrid
+
+import (
+	"math"
+	"slices"
+)
+
+// Shape is a closed polygon outline in pixels; the last point connects back
+// to the first.
+type Shape []Vector2
+
+const circleSegments = 200
+
+// Circle returns the circle of radius around (cx, cy) as a polygon of
+// circleSegments points.
+func Circle(cx, cy, radius float64) Shape {
+	var shape Shape
+	for i := 0; i < circleSegments; i++ {
+		angle := (float64(i) / float64(circleSegments)) * 2 * math.Pi
+		x := cx + radius*math.Cos(angle)
+		y := cy + radius*math.Sin(angle)
+		shape = append(shape, Vector2{x, y})
+	}
+	return shape
+}
+
+// Square returns a square of half-side half centered on (cx, cy), sampled
+// at the same angles as Circle so the two can be morphed point by point.
+func Square(cx, cy, half float64) Shape {
+	circle := Circle(0, 0, 1)
+	shape := make(Shape, len(circle))
+	for i, p := range circle {
+		// Push each point of the unit circle out along its ray to the square
+		r := half / math.Max(math.Abs(p.X), math.Abs(p.Y))
+		shape[i] = Vector2{cx + p.X*r, cy + p.Y*r}
+	}
+	return shape
+}
+
+// RegularPolygon returns the corners of a regular polygon with sides sides
+// inscribed in the circle of radius around (cx, cy), with a corner straight
+// up.
+func RegularPolygon(cx, cy, radius float64, sides int) Shape {
+	shape := make(Shape, sides)
+	for i := range shape {
+		angle := -math.Pi/2 + float64(i)/float64(sides)*2*math.Pi
+		shape[i] = Vector2{cx + radius*math.Cos(angle), cy + radius*math.Sin(angle)}
+	}
+	return shape
+}
+
+// Triangle returns an equilateral triangle pointing up, inscribed in the
+// circle of radius around (cx, cy).
+func Triangle(cx, cy, radius float64) Shape {
+	return RegularPolygon(cx, cy, radius, 3)
+}
+
+// Hexagon returns a regular hexagon inscribed in the circle of radius around
+// (cx, cy).
+func Hexagon(cx, cy, radius float64) Shape {
+	return RegularPolygon(cx, cy, radius, 6)
+}
+
+// Star returns a star of points tips pointing up, alternating between the
+// circles of radius outer and inner around (cx, cy).
+func Star(cx, cy, outer, inner float64, points int) Shape {
+	shape := make(Shape, 2*points)
+	for i := range shape {
+		r := outer
+		if i%2 == 1 {
+			r = inner
+		}
+		angle := -math.Pi/2 + float64(i)/float64(2*points)*2*math.Pi
+		shape[i] = Vector2{cx + r*math.Cos(angle), cy + r*math.Sin(angle)}
+	}
+	return shape
+}
+
+// Rect returns the corners of a w×h rectangle centered on (cx, cy).
+func Rect(cx, cy, w, h float64) Shape {
+	return Shape{
+		{cx - w/2, cy - h/2},
+		{cx + w/2, cy - h/2},
+		{cx + w/2, cy + h/2},
+		{cx - w/2, cy + h/2},
+	}
+}
+
+// Contains tests whether (px, py) is inside the polygon using ray casting: a
+// horizontal ray from the point crosses the outline an odd number of times
+// only when the point is inside. Works for concave polygons too. Points
+// exactly on the outline may land on either side.
+func (s Shape) Contains(px, py float64) bool {
+	inside := false
+	for i, j := 0, len(s)-1; i < len(s); j, i = i, i+1 {
+		a, b := s[i], s[j]
+		if (a.Y > py) != (b.Y > py) {
+			crossX := a.X + (py-a.Y)/(b.Y-a.Y)*(b.X-a.X)
+			if px < crossX {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// crossings appends to xs the x of every point where the horizontal line at
+// py crosses the outline, sorted, computed exactly as in Contains: (px, py) is
+// inside when an odd number of them lie to the right of px. Scanning a row
+// this way costs one pass over the outline instead of one per point.
+func (s Shape) crossings(py float64, xs []float64) []float64 {
+	for i, j := 0, len(s)-1; i < len(s); j, i = i, i+1 {
+		a, b := s[i], s[j]
+		if (a.Y > py) != (b.Y > py) {
+			xs = append(xs, a.X+(py-a.Y)/(b.Y-a.Y)*(b.X-a.X))
+		}
+	}
+	slices.Sort(xs)
+	return xs
+}
+
+// signedArea returns the shoelace area of the polygon, positive when its
+// points run from +x toward +y.
+func (s Shape) signedArea() float64 {
+	area := 0.0
+	for i, j := 0, len(s)-1; i < len(s); j, i = i, i+1 {
+		area += s[j].X*s[i].Y - s[i].X*s[j].Y
+	}
+	return area / 2
+}
+
+// ClosestEdge returns the point of the outline of shape nearest (x, y), and
+// the outward unit normal of its edge, the normal to reflect off when
+// crossing the outline there.
+func ClosestEdge(shape Shape, x, y float64) (point, normal Vector2) {
+	p := Vector2{x, y}
+	best := math.Inf(1)
+	outward := 1.0
+	if shape.signedArea() < 0 {
+		outward = -1
+	}
+	for i, j := 0, len(shape)-1; i < len(shape); j, i = i, i+1 {
+		a, b := shape[j], shape[i]
+		edge := b.Sub(a)
+		t := 0.0
+		if l2 := edge.Dot(edge); l2 > 0 {
+			t = math.Max(0, math.Min(1, p.Sub(a).Dot(edge)/l2))
+		}
+		q := a.Add(edge.Scale(t))
+		if d := p.Sub(q).Length(); d < best {
+			best = d
+			point = q
+			// The edge turned a quarter away from the interior
+			normal = Vector2{edge.Y, -edge.X}.Normalize().Scale(outward)
+		}
+	}
+	return point, normal
+}
+
+// edgeInset is how far inside the outline SnapInside moves points, in pixels.
+const edgeInset = 0.5
+
+// SnapInside returns (x, y) if it is inside s. Points outside but within
+// tolerance of the outline are moved just inside the nearest edge; for points
+// farther out ok is false.
+func (s Shape) SnapInside(x, y, tolerance float64) (p Vector2, ok bool) {
+	if s.Contains(x, y) {
+		return Vector2{x, y}, true
+	}
+	q, n := ClosestEdge(s, x, y)
+	toEdge := q.Sub(Vector2{x, y})
+	if toEdge.Length() > tolerance {
+		return Vector2{}, false
+	}
+	// Going in along the edge normal can come out again past a sharp corner,
+	// so also try going on the way the point came, and shorter steps
+	for inset := edgeInset; inset > edgeInset/64; inset /= 2 {
+		for _, in := range []Vector2{n.Scale(-1), toEdge.Normalize()} {
+			if p = q.Add(in.Scale(inset)); s.Contains(p.X, p.Y) {
+				return p, true
+			}
+		}
+	}
+	return Vector2{}, false
+}
+
+// bounds returns the axis-aligned bounding box of the polygon.
+func (s Shape) bounds() (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, p := range s {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+	return minX, minY, maxX, maxY
+}
@@ -0,0 +1,107 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// TestContainsConcave tests points of a U-shaped pond: in its arms and base,
+// in the notch between the arms, level with the notch corners, and outside.
+func TestContainsConcave(t *testing.T) {
+	u := Shape{{0, 0}, {30, 0}, {30, 30}, {20, 30}, {20, 10}, {10, 10}, {10, 30}, {0, 30}}
+	for _, c := range []struct {
+		x, y float64
+		want bool
+	}{
+		{5, 20, true},   // left arm
+		{25, 20, true},  // right arm
+		{15, 5, true},   // base
+		{5, 10, true},   // level with the notch corners, the ray runs along its bottom
+		{15, 20, false}, // in the notch
+		{15, 29, false}, // at the mouth of the notch
+		{40, 15, false}, // right of the pond
+		{15, -5, false}, // above it
+		{-5, 10, false}, // left of it, level with the notch corners
+	} {
+		if got := u.Contains(c.x, c.y); got != c.want {
+			t.Errorf("Contains(%g, %g) = %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+}
+
+// TestContainsBoundary tests points on the edge two squares share, vertical
+// and horizontal, and on the corner all four share. Each must be in exactly
+// one of them, so a click on a seam never counts for two ponds or for none.
+func TestContainsBoundary(t *testing.T) {
+	squares := []Shape{Rect(5, 5, 10, 10), Rect(15, 5, 10, 10), Rect(5, 15, 10, 10), Rect(15, 15, 10, 10)}
+	var points [][2]float64
+	for d := 0.5; d < 20; d++ {
+		points = append(points, [2]float64{10, d}, [2]float64{d, 10})
+	}
+	points = append(points, [2]float64{10, 10})
+	for _, p := range points {
+		n := 0
+		for _, s := range squares {
+			if s.Contains(p[0], p[1]) {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("(%g, %g) is in %d squares, want 1", p[0], p[1], n)
+		}
+	}
+}
+
+// TestShapeOutlines checks that every generated point lies on the outline its
+// generator promises, each around (100, 80): the circle's at its radius, the
+// square's on its sides, the corners of the regular shapes on their
+// circumcircle with equal sides between them, the star's tips alternating
+// between its two radii and the rectangle's at its corners.
+func TestShapeOutlines(t *testing.T) {
+	const cx, cy, r, tolerance = 100.0, 80.0, 50.0, 1e-9
+	on := func(name string, shape Shape, n int, dist func(i int, p Vector2) float64) {
+		t.Helper()
+		if len(shape) != n {
+			t.Errorf("%s has %d points, want %d", name, len(shape), n)
+		}
+		for i, p := range shape {
+			if d := dist(i, p); math.Abs(d) > tolerance {
+				t.Errorf("%s point %d at %v is %g off its outline", name, i, p, d)
+			}
+		}
+	}
+	radial := func(radius float64) func(int, Vector2) float64 {
+		return func(_ int, p Vector2) float64 { return math.Hypot(p.X-cx, p.Y-cy) - radius }
+	}
+
+	on("circle", Circle(cx, cy, r), circleSegments, radial(r))
+	on("square", Square(cx, cy, r), circleSegments, func(_ int, p Vector2) float64 {
+		return math.Max(math.Abs(p.X-cx), math.Abs(p.Y-cy)) - r
+	})
+	for _, c := range []struct {
+		name  string
+		shape Shape
+		sides int
+	}{
+		{"triangle", Triangle(cx, cy, r), 3},
+		{"hexagon", Hexagon(cx, cy, r), 6},
+	} {
+		side := 2 * r * math.Sin(math.Pi/float64(c.sides))
+		on(c.name, c.shape, c.sides, radial(r))
+		on(c.name+" side", c.shape, c.sides, func(i int, p Vector2) float64 {
+			return p.Sub(c.shape[(i+1)%c.sides]).Length() - side
+		})
+		if top := c.shape[0]; math.Abs(top.X-cx) > tolerance || math.Abs(top.Y-(cy-r)) > tolerance {
+			t.Errorf("%s starts at %v, want the corner straight up", c.name, top)
+		}
+	}
+	on("star", Star(cx, cy, r, r/2, 5), 10, func(i int, p Vector2) float64 {
+		if i%2 == 1 {
+			return radial(r/2)(i, p)
+		}
+		return radial(r)(i, p)
+	})
+	on("rect", Rect(cx, cy, 2*r, r), 4, func(_ int, p Vector2) float64 {
+		return math.Max(math.Abs(math.Abs(p.X-cx)-r), math.Abs(math.Abs(p.Y-cy)-r/2))
+	})
+}
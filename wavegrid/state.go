@@ -0,0 +1,197 @@
+package wavegrid
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// gridState is the gob form of a Grid: everything Step reads, plus the
+// shapes so the boundaries can be drawn again.
+type gridState struct {
+	GridWidth, GridHeight int
+	GridSize              float64
+	CX, CY                float64
+
+	Height, Velocity []float64
+	Mask             []bool
+	Obstacle         []bool
+	Region           []int
+	SpeedFactor      []float64
+	DampingFactor    []float64
+	Shapes           [][][2]float64
+
+	WaveSpeed    float64
+	Damping      float64
+	Reflectivity float64
+	Periodic     bool
+	Stencil      Stencil
+	Anisotropy   float64 // 0 in states saved before it existed
+
+	// Interfaces, nil in states saved before they existed
+	InterfaceCell []bool
+	Transmission  float64
+
+	// Wall ids, nil in states saved before walls could be toggled, when
+	// Obstacle holds all the walls there are
+	ObstacleID  []int
+	ObstacleOff []bool
+
+	// Shore and drivers, zero in states saved before they were kept: no
+	// shore, no pins or paddle and the quadratic impulse
+	ShoreWidth   int
+	ShoreDamping float64
+	Pins         []sourceState
+	Paddle       *paddleState
+	Impulse      ImpulseShape
+}
+
+// sourceState is the gob form of a ContinuousSource.
+type sourceState struct {
+	X, Y, Radius, Frequency, Amplitude float64
+	DT, T                              float64
+}
+
+// paddleState is the gob form of a Paddle.
+type paddleState struct {
+	Vertical             bool
+	Line                 int
+	Frequency, Amplitude float64
+	DT, T                float64
+}
+
+// Save writes the full simulation state of wg to w.
+func (wg *Grid) Save(w io.Writer) error {
+	shapes := make([][][2]float64, len(wg.shapes))
+	for i, shape := range wg.shapes {
+		for _, p := range shape {
+			shapes[i] = append(shapes[i], [2]float64{p.X, p.Y})
+		}
+	}
+	obstacles := make([]bool, len(wg.obstacle))
+	for i := range obstacles {
+		obstacles[i] = wg.obstacleOn(i)
+	}
+	pins := make([]sourceState, len(wg.Pins))
+	for i, p := range wg.Pins {
+		pins[i] = sourceState{p.X, p.Y, p.Radius, p.Frequency, p.Amplitude, p.dt, p.t}
+	}
+	var paddle *paddleState
+	if p := wg.Paddle; p != nil {
+		paddle = &paddleState{p.Vertical, p.Line, p.Frequency, p.Amplitude, p.dt, p.t}
+	}
+	return gob.NewEncoder(w).Encode(gridState{
+		GridWidth:     wg.gridWidth,
+		GridHeight:    wg.gridHeight,
+		GridSize:      wg.gridSize,
+		CX:            wg.cx,
+		CY:            wg.cy,
+		Height:        wg.height,
+		Velocity:      wg.velocity,
+		Mask:          wg.mask,
+		Obstacle:      obstacles,
+		Region:        wg.region,
+		SpeedFactor:   wg.speedFactor,
+		DampingFactor: wg.dampingFactor,
+		Shapes:        shapes,
+		WaveSpeed:     wg.waveSpeed,
+		Damping:       wg.damping,
+		Reflectivity:  wg.Reflectivity,
+		Periodic:      wg.periodic,
+		Stencil:       wg.stencil,
+		Anisotropy:    wg.anisotropy,
+
+		InterfaceCell: wg.interfaceCell,
+		Transmission:  wg.Transmission,
+
+		ObstacleID:  wg.obstacle,
+		ObstacleOff: wg.obstacleOff,
+
+		ShoreWidth:   wg.shoreWidth,
+		ShoreDamping: wg.shoreDamping,
+		Pins:         pins,
+		Paddle:       paddle,
+		Impulse:      wg.Impulse,
+	})
+}
+
+// Load reads a grid written by Save.
+func Load(r io.Reader) (*Grid, error) {
+	var s gridState
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	cells := s.GridWidth * s.GridHeight
+	if s.GridWidth <= 0 || s.GridHeight <= 0 || len(s.Height) != cells || len(s.Velocity) != cells || len(s.Mask) != cells || len(s.Obstacle) != cells ||
+		len(s.Region) != cells || len(s.SpeedFactor) != cells || len(s.DampingFactor) != cells {
+		return nil, fmt.Errorf("corrupt state: fields don't match a %dx%d grid", s.GridWidth, s.GridHeight)
+	}
+	if s.InterfaceCell == nil {
+		s.InterfaceCell, s.Transmission = make([]bool, cells), DefaultTransmission
+	} else if len(s.InterfaceCell) != cells {
+		return nil, fmt.Errorf("corrupt state: interfaces don't match a %dx%d grid", s.GridWidth, s.GridHeight)
+	}
+
+	if s.ObstacleID == nil {
+		s.ObstacleID = make([]int, cells)
+		for i, blocked := range s.Obstacle {
+			if blocked {
+				s.ObstacleID[i], s.ObstacleOff = 1, []bool{false}
+			}
+		}
+	} else if len(s.ObstacleID) != cells || slices.ContainsFunc(s.ObstacleID, func(id int) bool { return id < 0 || id > len(s.ObstacleOff) }) {
+		return nil, fmt.Errorf("corrupt state: walls don't match a %dx%d grid", s.GridWidth, s.GridHeight)
+	}
+
+	shapes := make([]Shape, len(s.Shapes))
+	for i, shape := range s.Shapes {
+		for _, p := range shape {
+			shapes[i] = append(shapes[i], Vector2{p[0], p[1]})
+		}
+	}
+	wg := &Grid{
+		height:       s.Height,
+		velocity:     s.Velocity,
+		mask:         s.Mask,
+		obstacle:     s.ObstacleID,
+		obstacleOff:  s.ObstacleOff,
+		nextVelocity: make([]float64, cells),
+		shapes:       shapes,
+		region:       s.Region,
+		cx:           s.CX,
+		cy:           s.CY,
+
+		gridWidth:  s.GridWidth,
+		gridHeight: s.GridHeight,
+		gridSize:   s.GridSize,
+
+		speedFactor:   s.SpeedFactor,
+		waveSpeed:     s.WaveSpeed,
+		damping:       s.Damping,
+		dampingFactor: s.DampingFactor,
+		shoreWidth:    s.ShoreWidth,
+		shoreDamping:  s.ShoreDamping,
+		Reflectivity:  s.Reflectivity,
+		periodic:      s.Periodic,
+		stencil:       s.Stencil,
+		anisotropy:    1,
+
+		interfaceCell: s.InterfaceCell,
+		Transmission:  s.Transmission,
+
+		Impulse: s.Impulse,
+	}
+	if s.Anisotropy > 0 {
+		wg.anisotropy = s.Anisotropy
+	}
+	for _, p := range s.Pins {
+		wg.Pins = append(wg.Pins, &ContinuousSource{p.X, p.Y, p.Radius, p.Frequency, p.Amplitude, p.DT, p.T})
+	}
+	if p := s.Paddle; p != nil {
+		wg.Paddle = &Paddle{p.Vertical, p.Line, p.Frequency, p.Amplitude, p.DT, p.T}
+	}
+	wg.initializeCoverage()
+	return wg, nil
+}
@@ -0,0 +1,53 @@
+package wavegrid
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSaveLoad saves a pond with a shore, a pin, a paddle and a non-default
+// impulse in the middle of a run, and loads it back. The copy must have
+// everything that was saved and go on exactly like the original.
+func TestSaveLoad(t *testing.T) {
+	p := testParams(200, 200)
+	p.ShoreWidth, p.ShoreDamping = 12, 0.97
+	wg := New(p, Circle(100, 100, 80))
+	wg.Impulse = Gaussian
+	wg.Pins = append(wg.Pins, NewContinuousSource(70, 100, 3, 5, 1, 1.0/300))
+	wg.Paddle = NewPaddle(true, 60, 6, 4, 1.0/300)
+	wg.AddWave(120, 90, testBrush)
+	for range 50 {
+		wg.Step()
+	}
+
+	var buf bytes.Buffer
+	if err := wg.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.shoreWidth != wg.shoreWidth || loaded.shoreDamping != wg.shoreDamping {
+		t.Errorf("shore = %d cells at %g, want %d at %g", loaded.shoreWidth, loaded.shoreDamping, wg.shoreWidth, wg.shoreDamping)
+	}
+	if loaded.Impulse != wg.Impulse {
+		t.Errorf("impulse = %v, want %v", loaded.Impulse, wg.Impulse)
+	}
+	if len(loaded.Pins) != 1 || *loaded.Pins[0] != *wg.Pins[0] {
+		t.Errorf("pins = %v, want %v", loaded.Pins, wg.Pins)
+	}
+	if loaded.Paddle == nil || *loaded.Paddle != *wg.Paddle {
+		t.Errorf("paddle = %v, want %v", loaded.Paddle, wg.Paddle)
+	}
+
+	for range 50 {
+		wg.Step()
+		loaded.Step()
+	}
+	for i := range wg.height {
+		if loaded.height[i] != wg.height[i] {
+			t.Fatalf("height of cell %d after 50 more steps = %g, want %g", i, loaded.height[i], wg.height[i])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package wavegrid
+
+import "math"
+
+// activeHeight is the smallest height a cell needs to count as active in
+// Stats. Damped ripples only approach zero, so exact zero would count every
+// cell a wave ever reached.
+const activeHeight = 1e-3
+
+// Stats are summary measures of the field at one moment, see Grid.Stats.
+type Stats struct {
+	MaxHeight   float64 // largest absolute height in the pond
+	ActiveCells int     // pond cells higher or lower than activeHeight
+	Energy      float64 // as returned by Grid.Energy
+}
+
+// Stats measures the field in a single pass over the pond, without
+// allocating, for a caller that wants several of the measures every frame.
+func (wg *Grid) Stats() Stats {
+	var s Stats
+	for y := 0; y < wg.gridHeight; y++ {
+		for x := 0; x < wg.gridWidth; x++ {
+			i := wg.Index(x, y)
+			if !wg.mask[i] {
+				continue
+			}
+			h := wg.height[i]
+			s.MaxHeight = math.Max(s.MaxHeight, math.Abs(h))
+			if math.Abs(h) > activeHeight {
+				s.ActiveCells++
+			}
+			vv, hkh, vkh := wg.energyTerms(x, y, i)
+			s.Energy += (vv + hkh + vkh) / 2
+		}
+	}
+	return s
+}
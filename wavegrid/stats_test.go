@@ -0,0 +1,49 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// TestStats spreads a click for a while, with a stray height left on the land
+// around the pond, and checks Stats against measures taken one at a time over
+// the pond cells alone. It must not allocate.
+func TestStats(t *testing.T) {
+	wg := New(testParams(200, 200), Circle(100, 100, 80))
+	wg.AddWave(100, 100, testBrush)
+	for range 100 {
+		wg.Step()
+	}
+	wg.height[wg.Index(0, 0)] = 5 // outside the circle
+
+	var maxHeight float64
+	active := 0
+	for y := range 200 {
+		for x := range 200 {
+			if !wg.InMask(x, y) {
+				continue
+			}
+			h := math.Abs(wg.HeightAt(x, y))
+			maxHeight = math.Max(maxHeight, h)
+			if h > activeHeight {
+				active++
+			}
+		}
+	}
+	vv, hkh, vkh := wg.energySums()
+	energy := (vv + hkh + vkh) / 2
+
+	s := wg.Stats()
+	if s.MaxHeight != maxHeight || s.MaxHeight == 0 {
+		t.Errorf("MaxHeight = %g, want %g", s.MaxHeight, maxHeight)
+	}
+	if s.ActiveCells != active || active == 0 {
+		t.Errorf("ActiveCells = %d, want %d", s.ActiveCells, active)
+	}
+	if math.Abs(s.Energy-energy) > 1e-12*energy || energy <= 0 {
+		t.Errorf("Energy = %g, want %g", s.Energy, energy)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { wg.Stats() }); allocs != 0 {
+		t.Errorf("Stats allocates %g times, want 0", allocs)
+	}
+}
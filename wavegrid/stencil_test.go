@@ -0,0 +1,59 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// Settings of TestStencilIsotropy: a small click is left to spread for
+// isotropySteps, short enough that it stays clear of the edges.
+const (
+	isotropyBrush = 3.0 // cells, small enough to carry waves a few cells long
+	isotropySteps = 300
+	isotropyGain  = 2.0 // how many times rounder the 9-point ripples must be
+)
+
+// TestStencilIsotropy spreads the same small click with each stencil and
+// compares how far the ripples are from round, see anisotropy. The 9-point
+// stencil must beat the 5-point one by isotropyGain.
+func TestStencilIsotropy(t *testing.T) {
+	measured := make(map[Stencil]float64)
+	for _, stencil := range []Stencil{Averaged, FivePoint, NinePoint} {
+		p := testParams(frontPondSize, frontPondSize)
+		p.Stencil = stencil
+		wg := frontPond(p, isotropyBrush)
+		for range isotropySteps {
+			wg.Step()
+		}
+		measured[stencil] = anisotropy(wg)
+		t.Logf("%s stencil: ripples %.1f%% from round", stencil, 100*measured[stencil])
+	}
+	if five, nine := measured[FivePoint], measured[NinePoint]; nine*isotropyGain > five {
+		t.Errorf("9-point stencil ripples are %.1f%% from round, not %.0f times rounder than the 5-point %.1f%%", 100*nine, isotropyGain, 100*five)
+	}
+}
+
+// anisotropy measures how far the field of wg is from circularly symmetric
+// around its center: the RMS difference between the heights on each circle
+// and their mean around it, relative to the RMS height. By symmetry of the
+// grid, one octant from the x axis to the diagonal covers every direction.
+func anisotropy(wg *Grid) float64 {
+	const angles = 33
+	width, height := wg.Size()
+	cx, cy := float64(width/2), float64(height/2)
+	var deviation, total float64
+	for r := 5.0; r < math.Min(cx, cy)-5; r += 0.5 {
+		var ring [angles]float64
+		mean := 0.0
+		for k := range ring {
+			angle := math.Pi / 4 * float64(k) / (angles - 1)
+			ring[k] = wg.SampleHeight(cx+r*math.Cos(angle), cy+r*math.Sin(angle))
+			mean += ring[k] / angles
+		}
+		for _, h := range ring {
+			deviation += (h - mean) * (h - mean)
+			total += h * h
+		}
+	}
+	return math.Sqrt(deviation / total)
+}
@@ -0,0 +1,41 @@
+package wavegrid
+
+import "math"
+
+type Vector2 struct {
+	X, Y float64
+}
+
+func (v Vector2) Add(o Vector2) Vector2 {
+	return Vector2{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vector2) Sub(o Vector2) Vector2 {
+	return Vector2{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vector2) Scale(s float64) Vector2 {
+	return Vector2{v.X * s, v.Y * s}
+}
+
+func (v Vector2) Dot(o Vector2) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+func (v Vector2) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Normalize returns v scaled to unit length, or the zero vector if v is zero.
+func (v Vector2) Normalize() Vector2 {
+	l := v.Length()
+	if l == 0 {
+		return Vector2{}
+	}
+	return v.Scale(1 / l)
+}
+
+// Reflect mirrors v off a surface with the given unit normal.
+func (v Vector2) Reflect(normal Vector2) Vector2 {
+	return v.Sub(normal.Scale(2 * v.Dot(normal)))
+}
@@ -0,0 +1,53 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVector2(t *testing.T) {
+	a, b := Vector2{3, 4}, Vector2{-1, 2}
+	if got, want := a.Add(b), (Vector2{2, 6}); got != want {
+		t.Errorf("Add = %v, want %v", got, want)
+	}
+	if got, want := a.Sub(b), (Vector2{4, 2}); got != want {
+		t.Errorf("Sub = %v, want %v", got, want)
+	}
+	if got, want := a.Scale(-2), (Vector2{-6, -8}); got != want {
+		t.Errorf("Scale = %v, want %v", got, want)
+	}
+	if got := a.Dot(b); got != 5 {
+		t.Errorf("Dot = %g, want 5", got)
+	}
+	if got := a.Length(); got != 5 {
+		t.Errorf("Length = %g, want 5", got)
+	}
+	if got, want := a.Normalize(), (Vector2{0.6, 0.8}); math.Abs(got.X-want.X) > 1e-15 || math.Abs(got.Y-want.Y) > 1e-15 {
+		t.Errorf("Normalize = %v, want %v", got, want)
+	}
+	if got := (Vector2{}).Normalize(); got != (Vector2{}) {
+		t.Errorf("Normalize of zero = %v, want zero", got)
+	}
+}
+
+// TestVector2Reflect bounces vectors off known walls: the part along the
+// normal flips and the part along the wall is kept.
+func TestVector2Reflect(t *testing.T) {
+	diagonal := Vector2{1, 1}.Normalize()
+	for _, c := range []struct {
+		v, normal, want Vector2
+	}{
+		{Vector2{3, -2}, Vector2{0, 1}, Vector2{3, 2}},    // off a floor
+		{Vector2{3, -2}, Vector2{-1, 0}, Vector2{-3, -2}}, // off a wall
+		{Vector2{1, 0}, diagonal, Vector2{0, -1}},         // off a 45° wall
+		{Vector2{2, 5}, Vector2{1, 0}, Vector2{-2, 5}},
+	} {
+		got := c.v.Reflect(c.normal)
+		if math.Abs(got.X-c.want.X) > 1e-12 || math.Abs(got.Y-c.want.Y) > 1e-12 {
+			t.Errorf("%v reflected off normal %v = %v, want %v", c.v, c.normal, got, c.want)
+		}
+		if math.Abs(got.Length()-c.v.Length()) > 1e-12 {
+			t.Errorf("%v reflected off normal %v changed length to %g", c.v, c.normal, got.Length())
+		}
+	}
+}
@@ -0,0 +1,860 @@
+// Package wavegrid solves the 2D wave equation on a grid of cells masked to
+// the inside of pond outlines. It has no rendering and no window: step a Grid
+// and read its Heights to draw them, or to analyze the field headless.
+package wavegrid
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	MinWaveSpeed        = 0.05
+	MaxWaveSpeed        = 1.5 // keep a margin below the CFL limit of the Averaged stencil (~1.63)
+	DefaultTransmission = 0.1 // coupling across painted interfaces, see Grid.Transmission
+)
+
+// laplacianNeighbors are the cells Step takes the Laplacian over: the 4 direct
+// neighbors, then the diagonals.
+var laplacianNeighbors = [...]struct{ dx, dy int }{
+	{0, -1}, {0, 1}, {-1, 0}, {1, 0},
+	{-1, -1}, {-1, 1}, {1, -1}, {1, 1},
+}
+
+// Grid stores every per-cell field row-major in a flat slice, indexed with
+// Index(x, y), so neighboring cells are close in memory.
+type Grid struct {
+	height   []float64
+	velocity []float64
+	mask     []bool
+
+	// nextVelocity is the spare buffer Step writes into before swapping it
+	// with velocity, so stepping doesn't allocate
+	nextVelocity []float64
+
+	// coverage is the fraction of each cell inside a pond, used only to
+	// anti-alias the drawn edge; the physics uses the boolean mask
+	coverage []float64
+
+	// obstacle holds the id of the wall carved over each cell at runtime, 0
+	// for none. Cells of walls that are on are outside the mask like land,
+	// but always reflect, even with absorbing boundaries. Walls toggled off
+	// keep their cells so they can be switched back on
+	obstacle    []int
+	obstacleOff []bool // by wall id minus one, walls toggled off
+
+	// interfaceCell marks pond cells painted as a semi-transparent plate.
+	// Step scales the Laplacian between cells on either side of its edge by
+	// Transmission, so waves are partly reflected and partly transmitted,
+	// like light at a glass pane. Short waves are reflected more: at 0.1
+	// about 40% of the energy of a default click gets through, at 0 none
+	interfaceCell []bool
+	Transmission  float64
+
+	shapes []Shape
+	region []int   // index of the shape each cell belongs to, -1 outside
+	cx, cy float64 // center of the area covered, in pixels
+
+	gridWidth, gridHeight int
+	gridSize              float64 // pixels per cell
+
+	speedFactor   []float64 // per-cell wave speed relative to waveSpeed, slower cells bend waves like a lens
+	waveSpeed     float64   // speed of the open water, the fastest cells
+	damping       float64
+	dampingFactor []float64 // per-cell damping relative to damping, lower near the shore
+	shoreWidth    int       // settings of the shore damping, kept to redo it when the mask changes
+	shoreDamping  float64
+	Reflectivity  float64 // 1 reflects waves off the pond edge fully, 0 absorbs them
+	stencil       Stencil
+	anisotropy    float64 // horizontal over vertical wave speed, see SetAnisotropy
+	periodic      bool    // the grid wraps around like a torus, see SetPeriodic
+	Paddle        *Paddle // nil unless a paddle is making plane waves
+	Pins          []*ContinuousSource
+	Held          map[int]float64 // cells pinned to a height by a finger, by index, see HoldFinger
+	Impulse       ImpulseShape    // profile of the disturbance AddWave makes
+
+	// TrackEnergy measures the energy change of every Step into
+	// EnergyDrift. ConserveEnergy does too, and then rescales the
+	// velocities to undo the change, like a thermostat
+	TrackEnergy    bool
+	ConserveEnergy bool
+	energyDrift    float64
+
+	// morphFrom and morphTo are the outlines the pond morphs between, nil
+	// unless morphing; morph is the current position between them and
+	// morphApplied the position the mask was last built for, see SetMorph
+	morphFrom, morphTo  Shape
+	morph, morphApplied float64
+}
+
+// Params are the settings of a new Grid.
+type Params struct {
+	Width, Height int     // pixels covered by the grid
+	GridSize      int     // pixels per grid cell
+	WaveSpeed     float64 // grid cells per step
+	Damping       float64
+	ShoreWidth    int     // cells from the edge over which extra damping fades in, 0 for none
+	ShoreDamping  float64 // damping right at the edge, relative to open water
+	Reflectivity  float64 // share of a wave the pond edge reflects, 0 to 1
+	Periodic      bool    // wrap the grid edges around, see SetPeriodic
+	Stencil       Stencil // discrete Laplacian, Averaged unless set
+	Anisotropy    float64 // horizontal over vertical wave speed, 0 for the same both ways
+}
+
+// New creates a grid covering the area of p whose mask is the union of the
+// interiors of the closed polygons in shapes, given in pixels. Separate
+// shapes act as independent ponds.
+func New(p Params, shapes ...Shape) *Grid {
+	gridWidth, gridHeight := p.Width/p.GridSize, p.Height/p.GridSize
+	wg := &Grid{
+		height:   make([]float64, gridWidth*gridHeight),
+		velocity: make([]float64, gridWidth*gridHeight),
+
+		nextVelocity: make([]float64, gridWidth*gridHeight),
+		mask:         make([]bool, gridWidth*gridHeight),
+		obstacle:     make([]int, gridWidth*gridHeight),
+
+		interfaceCell: make([]bool, gridWidth*gridHeight),
+		Transmission:  DefaultTransmission,
+
+		cx:     float64(p.Width) / 2,
+		cy:     float64(p.Height) / 2,
+		region: make([]int, gridWidth*gridHeight),
+		shapes: shapes,
+
+		speedFactor: make([]float64, gridWidth*gridHeight),
+
+		dampingFactor: make([]float64, gridWidth*gridHeight),
+
+		gridWidth:  gridWidth,
+		gridHeight: gridHeight,
+		gridSize:   float64(p.GridSize),
+
+		waveSpeed: p.WaveSpeed,
+		damping:   p.Damping,
+
+		shoreWidth:   p.ShoreWidth,
+		shoreDamping: p.ShoreDamping,
+
+		Reflectivity: p.Reflectivity,
+		periodic:     p.Periodic,
+		stencil:      p.Stencil,
+		anisotropy:   1,
+	}
+	if p.Anisotropy > 0 {
+		wg.anisotropy = p.Anisotropy
+	}
+
+	for i := range wg.speedFactor {
+		wg.speedFactor[i] = 1
+	}
+	// Bring a configured speed back into the stable range
+	wg.SetWaveSpeed(p.WaveSpeed)
+
+	wg.initializeMask()
+	wg.initializeCoverage()
+	wg.initializeDamping(p.ShoreWidth, p.ShoreDamping)
+	return wg
+}
+
+func (wg *Grid) initializeMask() {
+	clear(wg.mask)
+	for i := range wg.region {
+		wg.region[i] = -1
+	}
+
+	var xs []float64
+	for i, shape := range wg.shapes {
+		// Only cells inside the bounding box can be inside the polygon. Each
+		// row is tested against its crossings with the outline, which gives
+		// the same answer as Contains per cell but much faster
+		minX, minY, maxX, maxY := shape.bounds()
+		for y := max(0, int(minY/wg.gridSize)); y <= min(wg.gridHeight-1, int(maxY/wg.gridSize)); y++ {
+			py := float64(y) * wg.gridSize
+			xs = shape.crossings(py, xs[:0])
+			right := 0 // crossings at or left of px
+			for x := max(0, int(minX/wg.gridSize)); x <= min(wg.gridWidth-1, int(maxX/wg.gridSize)); x++ {
+				px := float64(x) * wg.gridSize
+				for right < len(xs) && xs[right] <= px {
+					right++
+				}
+				if j := wg.Index(x, y); wg.region[j] < 0 && (len(xs)-right)%2 == 1 {
+					wg.mask[j] = true
+					wg.region[j] = i
+				}
+			}
+		}
+	}
+}
+
+// coverageSamples is the number of subsamples per side used to measure the
+// coverage of cells along the edge of the mask.
+const coverageSamples = 4
+
+// initializeCoverage fills coverage: 1 or 0 away from the edge, and for cells
+// with a neighbor on the other side of the edge, the share of a grid of
+// subsamples around the cell's sample point that falls inside a pond.
+func (wg *Grid) initializeCoverage() {
+	wg.coverage = make([]float64, len(wg.mask))
+	wg.updateCoverage(0, 0, wg.gridWidth-1, wg.gridHeight-1)
+}
+
+// updateCoverage recomputes the coverage of the cells from (x0, y0) to
+// (x1, y1) inclusive, clipped to the grid.
+func (wg *Grid) updateCoverage(x0, y0, x1, y1 int) {
+	for y := max(0, y0); y <= min(wg.gridHeight-1, y1); y++ {
+		for x := max(0, x0); x <= min(wg.gridWidth-1, x1); x++ {
+			i := wg.Index(x, y)
+			if !wg.onMaskEdge(x, y) {
+				wg.coverage[i] = 0
+				if wg.mask[i] {
+					wg.coverage[i] = 1
+				}
+				continue
+			}
+
+			inside := 0
+			for sy := 0; sy < coverageSamples; sy++ {
+				for sx := 0; sx < coverageSamples; sx++ {
+					px := (float64(x) - 0.5 + (float64(sx)+0.5)/coverageSamples) * wg.gridSize
+					py := (float64(y) - 0.5 + (float64(sy)+0.5)/coverageSamples) * wg.gridSize
+					if wg.Contains(px, py) {
+						inside++
+					}
+				}
+			}
+			wg.coverage[i] = float64(inside) / (coverageSamples * coverageSamples)
+		}
+	}
+}
+
+// onMaskEdge reports whether any of the 8 neighbors of (x, y) is on the other
+// side of the mask edge.
+func (wg *Grid) onMaskEdge(x, y int) bool {
+	inside := wg.InMask(x, y)
+	for _, d := range laplacianNeighbors {
+		if wg.InMask(x+d.dx, y+d.dy) != inside {
+			return true
+		}
+	}
+	return false
+}
+
+// AddWave disturbs the water within radius cells of the pixel position (mx, my).
+func (wg *Grid) AddWave(mx, my, radius float64) {
+	wg.AddImpulse(mx, my, radius, ImpulseStrength)
+}
+
+// AddImpulse adds strength times the impulse profile to the velocities within
+// radius cells of the pixel position (mx, my). The position isn't rounded to a
+// cell: the profile is spread over the four cells around it with bilinear
+// weights, which sum to 1, so clicks a fraction of a cell apart make slightly
+// shifted waves of the same total strength instead of the same wave. Radii
+// under a cell are taken as one cell.
+func (wg *Grid) AddImpulse(mx, my, radius, strength float64) {
+	radius = math.Max(1, radius) // a radius of 0 would put 0/0 into the profile
+	fx, fy := mx/wg.gridSize, my/wg.gridSize
+	gridX, gridY := int(math.Floor(fx)), int(math.Floor(fy))
+	fx, fy = fx-float64(gridX), fy-float64(gridY)
+
+	// A click inside a shape only disturbs that shape, even near a neighbor
+	clickRegion := -1
+	if gridX >= 0 && gridX < wg.gridWidth && gridY >= 0 && gridY < wg.gridHeight {
+		clickRegion = wg.region[wg.Index(gridX, gridY)]
+	}
+
+	for _, c := range [4]struct {
+		dx, dy int
+		weight float64
+	}{
+		{0, 0, (1 - fx) * (1 - fy)},
+		{1, 0, fx * (1 - fy)},
+		{0, 1, (1 - fx) * fy},
+		{1, 1, fx * fy},
+	} {
+		if c.weight > 0 {
+			wg.stampImpulse(gridX+c.dx, gridY+c.dy, radius, c.weight*strength, clickRegion)
+		}
+	}
+}
+
+// stampImpulse adds strength times the impulse profile to the velocities
+// within radius cells of cell (gridX, gridY), leaving out cells of other
+// regions than clickRegion unless it is negative.
+func (wg *Grid) stampImpulse(gridX, gridY int, radius, strength float64, clickRegion int) {
+	// Add impulse with smooth falloff
+	for dy := -int(radius); dy <= int(radius); dy++ {
+		for dx := -int(radius); dx <= int(radius); dx++ {
+			x := gridX + dx
+			y := gridY + dy
+			if wg.InMask(x, y) &&
+				(clickRegion < 0 || wg.region[wg.Index(x, y)] == clickRegion) {
+				dist := math.Sqrt(float64(dx*dx + dy*dy))
+				if dist <= radius {
+					// Impulse to velocity (not height directly)
+					energy := strength * ImpulseProfile(wg.Impulse, dist/radius)
+					wg.velocity[wg.Index(x, y)] += energy
+				}
+			}
+		}
+	}
+}
+
+// stableTimeStep reports whether waveSpeed satisfies the CFL condition of
+// stencil on a grid whose cells are gridSize wide, in the same length unit.
+func stableTimeStep(waveSpeed, gridSize float64, stencil Stencil) bool {
+	courant := waveSpeed / gridSize // Δt is one step
+	return courant*courant <= stencil.maxCourantSquared()
+}
+
+// SetWaveSpeed changes the open-water wave speed, clamped to the range where
+// Step is stable. Slower regions keep their speed relative to the open water.
+func (wg *Grid) SetWaveSpeed(waveSpeed float64) {
+	wg.waveSpeed = math.Max(MinWaveSpeed, math.Min(MaxWaveSpeed, waveSpeed))
+	if !stableTimeStep(wg.waveSpeed, 1, wg.stencil) {
+		wg.waveSpeed = math.Sqrt(wg.stencil.maxCourantSquared())
+	}
+}
+
+// initializeDamping fills dampingFactor with 1, lowered within width cells of
+// the pond edge so waves lose energy on the way in, like on a sloping shore.
+// The extra damping fades in quadratically, reaching edgeDamping times the
+// open-water value at the edge itself.
+func (wg *Grid) initializeDamping(width int, edgeDamping float64) {
+	dist := wg.distanceToEdge()
+	for i, d := range dist {
+		factor := 1.0
+		if d >= 0 && d < width {
+			t := 1 - float64(d)/float64(width)
+			factor = 1 - (1-edgeDamping)*t*t
+		}
+		wg.dampingFactor[i] = factor
+	}
+}
+
+// distanceToEdge returns, for every cell, the number of 4-neighbor steps to the
+// nearest cell outside the mask, found with a breadth-first search outward from
+// the unmasked cells. Cells on the grid border count as one step from the edge,
+// unless the grid is periodic and the distance wraps around instead. Cells
+// with no edge to reach, on a periodic grid covered in water, are left at -1.
+func (wg *Grid) distanceToEdge() []int {
+	dist := make([]int, len(wg.mask))
+	var queue [][2]int
+	for y := 0; y < wg.gridHeight; y++ {
+		for x := 0; x < wg.gridWidth; x++ {
+			i := wg.Index(x, y)
+			switch {
+			case !wg.mask[i]:
+				dist[i] = 0
+				queue = append(queue, [2]int{x, y})
+			case !wg.periodic && (x == 0 || y == 0 || x == wg.gridWidth-1 || y == wg.gridHeight-1):
+				dist[i] = 1
+				queue = append(queue, [2]int{x, y})
+			default:
+				dist[i] = -1
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+			nx, ny := c[0]+d[0], c[1]+d[1]
+			if wg.periodic {
+				nx = (nx + wg.gridWidth) % wg.gridWidth
+				ny = (ny + wg.gridHeight) % wg.gridHeight
+			}
+			if nx >= 0 && nx < wg.gridWidth && ny >= 0 && ny < wg.gridHeight && dist[wg.Index(nx, ny)] < 0 {
+				dist[wg.Index(nx, ny)] = dist[wg.Index(c[0], c[1])] + 1
+				queue = append(queue, [2]int{nx, ny})
+			}
+		}
+	}
+	return dist
+}
+
+// SetDamping changes the open-water damping. Shore cells keep damping harder
+// than open water by the same factor.
+func (wg *Grid) SetDamping(damping float64) {
+	wg.damping = damping
+}
+
+// SetPeriodic makes the grid wrap around like a torus, so a neighbor off the
+// right edge is read from the left edge and a wave leaving one side comes back
+// on the other. It only matters where the mask reaches the grid border, so
+// pair it with a pond covering the whole grid.
+func (wg *Grid) SetPeriodic(on bool) {
+	wg.periodic = on
+	wg.initializeDamping(wg.shoreWidth, wg.shoreDamping)
+}
+
+// Periodic reports whether the grid wraps around, see SetPeriodic.
+func (wg *Grid) Periodic() bool {
+	return wg.periodic
+}
+
+// SetStencil switches the Laplacian Step uses, lowering the wave speed if it is
+// past the stability limit of the new stencil.
+func (wg *Grid) SetStencil(s Stencil) {
+	wg.stencil = s
+	wg.SetWaveSpeed(wg.waveSpeed)
+}
+
+// Stencil returns the Laplacian Step uses, see SetStencil.
+func (wg *Grid) Stencil() Stencil {
+	return wg.stencil
+}
+
+// SetAnisotropy makes the medium direction dependent, like a crystal: waves
+// run ratio times as fast horizontally as vertically, so a click spreads as
+// an ellipse. The faster direction keeps the wave speed and the slower one is
+// slowed down, so the solver stays as stable as it was.
+func (wg *Grid) SetAnisotropy(ratio float64) {
+	wg.anisotropy = ratio
+}
+
+// Anisotropy returns the horizontal over vertical wave speed, see SetAnisotropy.
+func (wg *Grid) Anisotropy() float64 {
+	return wg.anisotropy
+}
+
+// PaintSpeedCircle makes waves travel at factor times the open-water speed
+// inside the circle, e.g. 0.5 for a shallow region with twice the index.
+// The circle is given in pixels.
+func (wg *Grid) PaintSpeedCircle(cx, cy, radius, factor float64) {
+	cx, cy, radius = cx/wg.gridSize, cy/wg.gridSize, radius/wg.gridSize
+	for y := max(0, int(cy-radius)); y <= min(wg.gridHeight-1, int(cy+radius)); y++ {
+		for x := max(0, int(cx-radius)); x <= min(wg.gridWidth-1, int(cx+radius)); x++ {
+			dx := float64(x) - cx
+			dy := float64(y) - cy
+			if dx*dx+dy*dy <= radius*radius {
+				wg.speedFactor[wg.Index(x, y)] = factor
+			}
+		}
+	}
+}
+
+// PaintSpeedRect makes waves travel at factor times the open-water speed in
+// the cells from pixel (x0, y0) up to (x1, y1), like PaintSpeedCircle.
+func (wg *Grid) PaintSpeedRect(x0, y0, x1, y1, factor float64) {
+	for y := max(0, int(math.Ceil(y0/wg.gridSize))); y <= min(wg.gridHeight-1, int(y1/wg.gridSize)); y++ {
+		for x := max(0, int(math.Ceil(x0/wg.gridSize))); x <= min(wg.gridWidth-1, int(x1/wg.gridSize)); x++ {
+			wg.speedFactor[wg.Index(x, y)] = factor
+		}
+	}
+}
+
+// ClearSpeedRegions removes every region painted with PaintSpeedCircle or
+// PaintSpeedRect, so the whole pond is open water again.
+func (wg *Grid) ClearSpeedRegions() {
+	for i := range wg.speedFactor {
+		wg.speedFactor[i] = 1
+	}
+}
+
+// Step advances the simulation by one time step.
+func (wg *Grid) Step() {
+	if wg.TrackEnergy || wg.ConserveEnergy {
+		wg.trackEnergy()
+		return
+	}
+	wg.step()
+}
+
+func (wg *Grid) step() {
+	// Driven sources push on the water before it moves
+	for _, p := range wg.Pins {
+		p.force(wg)
+	}
+
+	// Apply velocity to height
+	for i, inside := range wg.mask {
+		if inside {
+			wg.height[i] += wg.velocity[i]
+		}
+	}
+	if wg.Paddle != nil {
+		wg.Paddle.drive(wg)
+	}
+	wg.hold()
+
+	// Calculate new velocities using wave equation, into the spare buffer
+	newVelocity := wg.nextVelocity
+
+	// A periodic grid updates its border cells too, wrapping their neighbors
+	// around to the far side, so there is no border to hold at zero
+	border := 1
+	if wg.periodic {
+		border = 0
+	}
+	for y := border; y < wg.gridHeight-border; y++ {
+		for x := border; x < wg.gridWidth-border; x++ {
+			i := wg.Index(x, y)
+			if !wg.mask[i] {
+				newVelocity[i] = 0
+				continue
+			}
+
+			laplacian := wg.laplacian(x, y, i)
+
+			// Wave acceleration based on Laplacian, with the local speed so waves
+			// bend when crossing into a slower region
+			c := wg.waveSpeed * wg.speedFactor[i]
+			acceleration := laplacian * c * c
+			newVelocity[i] = (wg.velocity[i] + acceleration) * (wg.damping * wg.dampingFactor[i])
+		}
+	}
+
+	wg.velocity, wg.nextVelocity = newVelocity, wg.velocity
+	if wg.periodic {
+		return
+	}
+
+	// Zero out height at boundaries, and velocity which the loop above skips
+	for x := 0; x < wg.gridWidth; x++ {
+		top, bottom := wg.Index(x, 0), wg.Index(x, wg.gridHeight-1)
+		wg.height[top], wg.height[bottom] = 0, 0
+		wg.velocity[top], wg.velocity[bottom] = 0, 0
+	}
+	for y := 0; y < wg.gridHeight; y++ {
+		left, right := wg.Index(0, y), wg.Index(wg.gridWidth-1, y)
+		wg.height[left], wg.height[right] = 0, 0
+		wg.velocity[left], wg.velocity[right] = 0, 0
+	}
+}
+
+// laplacian returns the Laplacian of the stencil at cell i at (x, y), with the
+// boundaries of Step applied to the neighbors outside the mask.
+func (wg *Grid) laplacian(x, y, i int) float64 {
+	neighbors := laplacianNeighbors[:]
+	if wg.stencil == FivePoint {
+		neighbors = neighbors[:4]
+	}
+
+	// Sum of the height differences to the neighbors, and partial sums up to
+	// the horizontal ones and the diagonals, which come in that order
+	laplacian, vertical, direct := 0.0, 0.0, 0.0
+	for j, d := range neighbors {
+		switch j {
+		case 2:
+			vertical = laplacian
+		case 4:
+			direct = laplacian
+		}
+		nx := x + d.dx
+		ny := y + d.dy
+		if wg.periodic {
+			nx = (nx + wg.gridWidth) % wg.gridWidth
+			ny = (ny + wg.gridHeight) % wg.gridHeight
+		}
+
+		if nx >= 0 && nx < wg.gridWidth && ny >= 0 && ny < wg.gridHeight {
+			if n := wg.Index(nx, ny); wg.mask[n] {
+				if wg.interfaceCell[n] != wg.interfaceCell[i] {
+					// Across an interface the link is weakened, so the
+					// cells pull on each other less and part of a wave
+					// bounces back. It is the same both ways, so it
+					// conserves energy
+					laplacian += wg.Transmission * (wg.height[n] - wg.height[i])
+				} else {
+					laplacian += wg.height[n] - wg.height[i]
+				}
+			} else if wg.obstacleOn(n) {
+				// Obstacles are hard walls: mirror (perfect reflection)
+				laplacian += -wg.height[i]
+			} else {
+				// Blend the mirror with the Mur first-order radiation condition,
+				// where the cell beyond the edge follows the one-way wave equation
+				// dh/dn = -(1/c)·dh/dt so outgoing waves leave instead of bouncing back
+				mirror := -wg.height[i]
+				radiating := -wg.velocity[i] / (wg.waveSpeed * wg.speedFactor[i])
+				laplacian += wg.Reflectivity*mirror + (1-wg.Reflectivity)*radiating
+			}
+		}
+	}
+
+	if len(neighbors) == 4 {
+		direct = laplacian
+	}
+	if wg.anisotropy != 1 {
+		return wg.stencil.anisotropic(vertical, direct-vertical, laplacian-direct, wg.anisotropy)
+	}
+
+	switch wg.stencil {
+	case FivePoint:
+		return laplacian * 3 / 8
+	case NinePoint:
+		// The direct neighbors weigh 1/16 + 3/16 = 1/4, the diagonals 1/16
+		return (laplacian + 3*direct) / 16
+	}
+	return laplacian / float64(len(laplacianNeighbors))
+}
+
+// Energy returns the energy of the waves in the form the update of Step holds
+// constant: ½v·v + ½h·Kh + ½v·Kh, where Kh is minus c² times the Laplacian.
+// Without damping, absorbing edges, drivers or speed changes it stays the same
+// to rounding error, on a periodic grid as well as in a walled pond.
+func (wg *Grid) Energy() float64 {
+	return wg.Stats().Energy
+}
+
+// Contains reports whether the pixel position (px, py) is inside any pond.
+func (wg *Grid) Contains(px, py float64) bool {
+	for _, shape := range wg.shapes {
+		if shape.Contains(px, py) {
+			return true
+		}
+	}
+	return false
+}
+
+// SnapToMask returns the pixel position (px, py) if its cell is in a pond,
+// or else the sample point of the nearest pond cell within tolerance pixels
+// plus a cell, since the edge cells may be sampled up to a cell inside the
+// outline. This way clicks on the drawn edge still land. ok is false when
+// there is no such cell.
+func (wg *Grid) SnapToMask(px, py, tolerance float64) (x, y float64, ok bool) {
+	cellX, cellY := int(px/wg.gridSize), int(py/wg.gridSize)
+	if wg.InMask(cellX, cellY) {
+		return px, py, true
+	}
+	best := tolerance + wg.gridSize
+	reach := int(math.Ceil(best/wg.gridSize)) + 1
+	for cy := cellY - reach; cy <= cellY+reach; cy++ {
+		for cx := cellX - reach; cx <= cellX+reach; cx++ {
+			if !wg.InMask(cx, cy) {
+				continue
+			}
+			sx, sy := float64(cx)*wg.gridSize, float64(cy)*wg.gridSize
+			if d := math.Hypot(sx-px, sy-py); d <= best {
+				best = d
+				x, y, ok = sx, sy, true
+			}
+		}
+	}
+	return x, y, ok
+}
+
+// BeginObstacle starts a new wall, which the following SetObstacle calls
+// carve, so it can be toggled separately from the walls before it.
+func (wg *Grid) BeginObstacle() {
+	wg.obstacleOff = append(wg.obstacleOff, false)
+}
+
+// SetObstacle turns the pond cells within radius cells of cell (x, y) into an
+// obstacle, a wall waves reflect and diffract around. The cells join the wall
+// last started with BeginObstacle.
+func (wg *Grid) SetObstacle(x, y, radius int) {
+	if len(wg.obstacleOff) == 0 {
+		wg.BeginObstacle()
+	}
+	id := len(wg.obstacleOff)
+	for cy := max(0, y-radius); cy <= min(wg.gridHeight-1, y+radius); cy++ {
+		for cx := max(0, x-radius); cx <= min(wg.gridWidth-1, x+radius); cx++ {
+			dx, dy := cx-x, cy-y
+			if i := wg.Index(cx, cy); wg.mask[i] && dx*dx+dy*dy <= radius*radius {
+				wg.carveObstacle(i, id)
+			}
+		}
+	}
+}
+
+// carveObstacle makes cell i part of wall id, taking it out of the water
+// unless the wall is toggled off.
+func (wg *Grid) carveObstacle(i, id int) {
+	wg.obstacle[i] = id
+	if !wg.obstacleOff[id-1] {
+		wg.mask[i] = false
+		wg.height[i], wg.velocity[i] = 0, 0
+	}
+}
+
+// obstacleOn reports whether cell i is part of a wall that is on.
+func (wg *Grid) obstacleOn(i int) bool {
+	id := wg.obstacle[i]
+	return id > 0 && !wg.obstacleOff[id-1]
+}
+
+// ToggleObstacle switches wall id on or off and reports whether it is on now.
+// Only its own cells change: they turn into still water where they are
+// inside the pond, or back into wall, while the waves around them keep going.
+func (wg *Grid) ToggleObstacle(id int) bool {
+	on := wg.obstacleOff[id-1]
+	wg.obstacleOff[id-1] = !on
+
+	x0, y0, x1, y1 := wg.gridWidth, wg.gridHeight, -1, -1
+	for i, cell := range wg.obstacle {
+		if cell != id {
+			continue
+		}
+		if on {
+			wg.mask[i] = false
+			wg.height[i], wg.velocity[i] = 0, 0
+		} else {
+			wg.mask[i] = wg.region[i] >= 0
+		}
+		x, y := i%wg.gridWidth, i/wg.gridWidth
+		x0, y0, x1, y1 = min(x0, x), min(y0, y), max(x1, x), max(y1, y)
+	}
+	// Only cells next to the wall can have gained or lost an edge
+	wg.updateCoverage(x0-1, y0-1, x1+1, y1+1)
+	return on
+}
+
+// ObstacleID returns the id of the wall carved over cell i, whether it is on
+// or off, or 0 if there is none.
+func (wg *Grid) ObstacleID(i int) int {
+	return wg.obstacle[i]
+}
+
+// ClearObstacles removes every wall carved with SetObstacle, turning the
+// cells back into still water where they are inside the pond.
+func (wg *Grid) ClearObstacles() {
+	clear(wg.obstacle)
+	wg.obstacleOff = nil
+	wg.setShapes(wg.shapes)
+}
+
+// SetInterface paints the pond cells within radius cells of cell (x, y) as an
+// interface when on is set, or clears them.
+func (wg *Grid) SetInterface(x, y, radius int, on bool) {
+	for cy := max(0, y-radius); cy <= min(wg.gridHeight-1, y+radius); cy++ {
+		for cx := max(0, x-radius); cx <= min(wg.gridWidth-1, x+radius); cx++ {
+			dx, dy := cx-x, cy-y
+			if i := wg.Index(cx, cy); wg.mask[i] && dx*dx+dy*dy <= radius*radius {
+				wg.interfaceCell[i] = on
+			}
+		}
+	}
+}
+
+// Index returns the position of cell (x, y) in the flat grids.
+func (wg *Grid) Index(x, y int) int {
+	return y*wg.gridWidth + x
+}
+
+// InMask reports whether (x, y) is a cell of the grid inside a pond.
+func (wg *Grid) InMask(x, y int) bool {
+	return x >= 0 && x < wg.gridWidth && y >= 0 && y < wg.gridHeight && wg.mask[wg.Index(x, y)]
+}
+
+// HeightAt returns the height of a cell, or 0 outside the grid or mask, which
+// is how the mirror boundary in Step sees its neighbors.
+func (wg *Grid) HeightAt(x, y int) float64 {
+	if !wg.InMask(x, y) {
+		return 0
+	}
+	return wg.height[wg.Index(x, y)]
+}
+
+// SampleHeight returns the height at (x, y) in cell coordinates, interpolated
+// between the four cells around it.
+func (wg *Grid) SampleHeight(x, y float64) float64 {
+	return wg.bilinear(wg.height, x, y)
+}
+
+// Heights returns the height of every cell, row-major and indexed with Index.
+// It is the grid's own slice, so it follows every Step and writes to it
+// reshape the water.
+func (wg *Grid) Heights() []float64 {
+	return wg.height
+}
+
+// Velocities returns the vertical velocity of every cell, like Heights.
+func (wg *Grid) Velocities() []float64 {
+	return wg.velocity
+}
+
+// Size returns the number of cells across and down.
+func (wg *Grid) Size() (width, height int) {
+	return wg.gridWidth, wg.gridHeight
+}
+
+// CellSize returns the width of a cell in pixels.
+func (wg *Grid) CellSize() float64 {
+	return wg.gridSize
+}
+
+// Center returns the center of the area covered by the grid, in pixels.
+func (wg *Grid) Center() (x, y float64) {
+	return wg.cx, wg.cy
+}
+
+// Coverage returns the fraction of cell i inside a pond, for anti-aliasing
+// the edge when drawing.
+func (wg *Grid) Coverage(i int) float64 {
+	return wg.coverage[i]
+}
+
+// Obstacle reports whether cell i was carved out with SetObstacle, in a wall
+// that is on.
+func (wg *Grid) Obstacle(i int) bool {
+	return wg.obstacleOn(i)
+}
+
+// Interface reports whether cell i is painted as an interface, see
+// SetInterface.
+func (wg *Grid) Interface(i int) bool {
+	return wg.interfaceCell[i]
+}
+
+// Speed returns the wave speed of cell (x, y), lower than WaveSpeed in
+// regions painted with PaintSpeedCircle.
+func (wg *Grid) Speed(x, y int) float64 {
+	return wg.waveSpeed * wg.speedFactor[wg.Index(x, y)]
+}
+
+// WaveSpeed returns the speed of the open water, in cells per step.
+func (wg *Grid) WaveSpeed() float64 {
+	return wg.waveSpeed
+}
+
+// Damping returns the open-water damping, the share of velocity kept each
+// step.
+func (wg *Grid) Damping() float64 {
+	return wg.damping
+}
+
+// ClearField brings the water back to rest, keeping the shape, obstacles and
+// medium settings.
+func (wg *Grid) ClearField() {
+	clear(wg.height)
+	clear(wg.velocity)
+}
+
+// Finite reports whether every height and velocity is a finite number. A
+// single NaN or Inf reaches the whole pond within a few steps.
+func (wg *Grid) Finite() bool {
+	for i := range wg.height {
+		if math.IsNaN(wg.height[i]) || math.IsInf(wg.height[i], 0) ||
+			math.IsNaN(wg.velocity[i]) || math.IsInf(wg.velocity[i], 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxHeight returns the largest absolute height inside the mask, used to auto-normalize the colors.
+func (wg *Grid) MaxHeight() float64 {
+	maxH := 0.0
+	for i, inside := range wg.mask {
+		if inside {
+			maxH = math.Max(maxH, math.Abs(wg.height[i]))
+		}
+	}
+	return maxH
+}
+
+// FieldHash returns an FNV-1a hash of the exact bits of the height field, so
+// any change to the solver numerics, however small, changes it.
+func (wg *Grid) FieldHash() uint64 {
+	buf := make([]byte, 0, 8*len(wg.height))
+	for _, h := range wg.height {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(h))
+	}
+	h := fnv.New64a()
+	h.Write(buf)
+	return h.Sum64()
+}
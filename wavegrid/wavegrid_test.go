@@ -0,0 +1,542 @@
+package wavegrid
+
+import (
+	"math"
+	"testing"
+)
+
+// testBrush is the brush radius of a default click in grid mode, in cells.
+const testBrush = 8.0
+
+// testParams returns the settings of the default pond of grid mode on a
+// width×height screen, without damping or a shore.
+func testParams(width, height int) Params {
+	return Params{Width: width, Height: height, GridSize: 1, WaveSpeed: 0.5, Damping: 1, Reflectivity: 1}
+}
+
+// TestSetDampingFromZero raises the damping of a pond that stopped every wave
+// dead. The water must stay finite, and the shore must still damp harder than
+// the open water by the factor it had.
+func TestSetDampingFromZero(t *testing.T) {
+	p := testParams(200, 200)
+	p.Damping = 0
+	p.ShoreWidth, p.ShoreDamping = 12, 0.97
+	wg := New(p, Circle(100, 100, 80))
+	wg.AddWave(100, 100, testBrush)
+	wg.Step()
+
+	shore := -1
+	for i, f := range wg.dampingFactor {
+		if wg.mask[i] && f < 1 {
+			shore = i
+			break
+		}
+	}
+	if shore < 0 {
+		t.Fatal("no shore cell damps harder than open water")
+	}
+	factor := wg.dampingFactor[shore]
+
+	wg.SetDamping(0.9)
+	wg.AddWave(100, 100, testBrush)
+	for range 50 {
+		wg.Step()
+	}
+	if !wg.Finite() {
+		t.Fatal("field is not finite after raising the damping from 0")
+	}
+	if got, want := wg.damping*wg.dampingFactor[shore], 0.9*factor; got != want {
+		t.Errorf("damping at shore cell %d = %g, want %g", shore, got, want)
+	}
+}
+
+// TestZeroRadius clicks and presses a finger with a radius of 0, which must
+// act as one cell instead of dividing 0 by 0 into the field.
+func TestZeroRadius(t *testing.T) {
+	wg := New(testParams(200, 200), Circle(100, 100, 80))
+	wg.AddImpulse(100.5, 100.5, 0, ImpulseStrength)
+	if v := wg.velocity[wg.Index(100, 100)]; math.IsNaN(v) || v == 0 {
+		t.Fatalf("velocity under a click of radius 0 = %g, want a finite kick", v)
+	}
+	wg.Held = wg.HoldFinger(60, 100, 0, -5, nil)
+	if len(wg.Held) == 0 {
+		t.Fatal("a finger of radius 0 holds no cell")
+	}
+	for range 100 {
+		wg.Step()
+	}
+	if !wg.Finite() {
+		t.Fatal("field is not finite after a click of radius 0")
+	}
+}
+
+// TestShoreDamping drops the same pulse in the middle of a pond with a
+// shore and next to its edge, and lets both run for less time than the middle
+// one needs to reach the shore. The one by the edge must lose a good part of
+// its energy, while the one in the middle keeps all of it.
+func TestShoreDamping(t *testing.T) {
+	left := func(x, y float64) float64 {
+		p := testParams(200, 200)
+		p.ShoreWidth, p.ShoreDamping = 12, 0.97
+		wg := New(p, Circle(100, 100, 80))
+		wg.AddWave(x, y, testBrush)
+		start := wg.Energy()
+		for range 150 {
+			wg.Step()
+		}
+		return wg.Energy() / start
+	}
+	middle, edge := left(100, 100), left(100, 30)
+	if middle < 0.99 {
+		t.Errorf("%.1f%% of the energy of a pulse in the middle left, want it all", 100*middle)
+	}
+	if edge > 0.9 {
+		t.Errorf("%.1f%% of the energy of a pulse by the edge left, want under 90%%", 100*edge)
+	}
+}
+
+// BenchmarkGridStep times one Step of the default pond with a wave in it,
+// reporting allocations: Step swaps preallocated buffers, so there must be
+// none.
+func BenchmarkGridStep(b *testing.B) {
+	p := testParams(1000, 600)
+	p.ShoreWidth, p.ShoreDamping = 12, 0.97
+	wg := New(p, Circle(500, 300, 150))
+	wg.AddWave(500, 300, testBrush)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		wg.Step()
+	}
+}
+
+// BenchmarkFieldLayout sweeps the averaged 8-neighbor Laplacian of Step over
+// a field the size of the default pond, stored in one flat slice indexed like
+// Grid's fields, and as a slice of separately allocated rows, the layout Grid
+// used before. The flat sweep reads each row from contiguous memory without
+// going through a row header.
+func BenchmarkFieldLayout(b *testing.B) {
+	const width, height = 1000, 600
+	flat := make([]float64, width*height)
+	flatOut := make([]float64, width*height)
+	rows := make([][]float64, height)
+	rowsOut := make([][]float64, height)
+	for y := range rows {
+		rows[y] = make([]float64, width)
+		rowsOut[y] = make([]float64, width)
+		for x := range rows[y] {
+			h := math.Sin(float64(x)/7) * math.Cos(float64(y)/5)
+			rows[y][x], flat[y*width+x] = h, h
+		}
+	}
+
+	b.Run("flat", func(b *testing.B) {
+		for range b.N {
+			for y := 1; y < height-1; y++ {
+				for x := 1; x < width-1; x++ {
+					i := y*width + x
+					sum := 0.0
+					for _, d := range laplacianNeighbors {
+						sum += flat[i+d.dy*width+d.dx] - flat[i]
+					}
+					flatOut[i] = sum / 8
+				}
+			}
+		}
+	})
+	b.Run("rows", func(b *testing.B) {
+		for range b.N {
+			for y := 1; y < height-1; y++ {
+				for x := 1; x < width-1; x++ {
+					sum := 0.0
+					for _, d := range laplacianNeighbors {
+						sum += rows[y+d.dy][x+d.dx] - rows[y][x]
+					}
+					rowsOut[y][x] = sum / 8
+				}
+			}
+		}
+	})
+}
+
+// TestUnstableSpeedClamped asks every stencil for a wave speed far beyond its
+// CFL limit. The grid must bring the speed back to the stencil's SpeedLimit,
+// inside the stable range, where a drop rings around the undamped pond with
+// its energy conserved instead of blowing up.
+func TestUnstableSpeedClamped(t *testing.T) {
+	for _, s := range []Stencil{Averaged, FivePoint, NinePoint} {
+		p := testParams(120, 120)
+		p.WaveSpeed, p.Stencil = 10, s
+		wg := New(p, Circle(60, 60, 50))
+		if limit := math.Sqrt(s.maxCourantSquared()); wg.WaveSpeed() > limit {
+			t.Errorf("stencil %v: speed %g is above the CFL limit %g", s, wg.WaveSpeed(), limit)
+			continue
+		}
+		if wg.WaveSpeed() != s.SpeedLimit() {
+			t.Errorf("stencil %v: speed %g, want its speed limit %g", s, wg.WaveSpeed(), s.SpeedLimit())
+		}
+		wg.AddWave(60, 60, testBrush)
+		start := wg.Energy()
+		for range 1000 {
+			wg.Step()
+		}
+		if e := wg.Energy(); !wg.Finite() || math.Abs(e-start) > 1e-9*start {
+			t.Errorf("stencil %v at speed %g: energy %g after 1000 steps, want %g", s, wg.WaveSpeed(), e, start)
+		}
+	}
+}
+
+// TestSetWaveSpeedFromZero starts a pond with a slow region at zero speed and
+// then speeds it up. The slow region must keep its speed relative to the open
+// water instead of turning into NaN.
+func TestSetWaveSpeedFromZero(t *testing.T) {
+	p := testParams(200, 200)
+	p.WaveSpeed = 0
+	wg := New(p, Circle(100, 100, 80))
+	wg.PaintSpeedCircle(100, 100, 20, 0.5)
+
+	wg.SetWaveSpeed(0.5)
+	if got := wg.Speed(100, 100); got != 0.25 {
+		t.Errorf("speed in the slow region = %g, want 0.25", got)
+	}
+	if got := wg.Speed(50, 100); got != 0.5 {
+		t.Errorf("speed in open water = %g, want 0.5", got)
+	}
+	wg.AddWave(100, 100, testBrush)
+	for range 50 {
+		wg.Step()
+	}
+	if !wg.Finite() {
+		t.Fatal("field is not finite after raising the wave speed from 0")
+	}
+}
+
+// TestToggleObstacle closes a pond off with a wall, then opens it again while
+// a wave is running. The wave must only get through while the wall is off,
+// and toggling must leave the rest of the field alone.
+func TestToggleObstacle(t *testing.T) {
+	wg := New(testParams(200, 100), Rect(100, 50, 200, 100))
+	wg.SetObstacle(0, 0, 0) // a separate wall that stays on
+	wg.BeginObstacle()
+	for y := range 100 {
+		wg.SetObstacle(100, y, 1)
+	}
+	id := wg.ObstacleID(wg.Index(100, 50))
+	if id != 2 || wg.ObstacleID(0) != 1 {
+		t.Fatalf("wall ids = %d and %d, want 1 and 2", wg.ObstacleID(0), id)
+	}
+
+	rightEnergy := func() float64 {
+		e := 0.0
+		for y := range 100 {
+			for x := 110; x < 200; x++ {
+				h := wg.HeightAt(x, y)
+				e += h * h
+			}
+		}
+		return e
+	}
+	wg.AddWave(50, 50, testBrush)
+	for range 300 {
+		wg.Step()
+	}
+	if e := rightEnergy(); e != 0 {
+		t.Fatalf("energy %g got through the wall", e)
+	}
+
+	before := wg.Heights()[wg.Index(50, 50)]
+	if wg.ToggleObstacle(id) {
+		t.Fatal("ToggleObstacle reported the wall on after switching it off")
+	}
+	if got := wg.Heights()[wg.Index(50, 50)]; got != before {
+		t.Errorf("height at (50, 50) changed from %g to %g on toggling", before, got)
+	}
+	if !wg.InMask(100, 50) || wg.Obstacle(wg.Index(100, 50)) || !wg.Obstacle(0) {
+		t.Fatal("toggling one wall off didn't open exactly its cells")
+	}
+	for range 300 {
+		wg.Step()
+	}
+	if e := rightEnergy(); e == 0 {
+		t.Fatal("no energy got through the wall after switching it off")
+	}
+
+	if !wg.ToggleObstacle(id) {
+		t.Fatal("ToggleObstacle reported the wall off after switching it back on")
+	}
+	if wg.InMask(100, 50) || wg.HeightAt(100, 50) != 0 || wg.Velocities()[wg.Index(100, 50)] != 0 {
+		t.Error("wall cells aren't still land after switching it back on")
+	}
+	if !wg.Finite() {
+		t.Error("field is not finite after toggling")
+	}
+}
+
+// lShape is an L of two 60-pixel arms meeting at the top left, with the notch
+// at the bottom right: a concave pond inside the box from (20, 20) to
+// (140, 140).
+var lShape = Shape{{20, 20}, {140, 20}, {140, 80}, {80, 80}, {80, 140}, {20, 140}}
+
+// TestMaskSquareAndL builds the mask of a square and an L-shaped pond. Known
+// points must land on the right side, and every cell must agree with Contains
+// at its sample point.
+func TestMaskSquareAndL(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		shape   Shape
+		inside  [][2]int
+		outside [][2]int
+	}{
+		{"square", Square(80, 80, 50), [][2]int{{80, 80}, {31, 31}, {129, 80}}, [][2]int{{29, 80}, {80, 131}, {0, 0}}},
+		{"L", lShape, [][2]int{{30, 30}, {130, 30}, {30, 130}, {79, 79}}, [][2]int{{100, 100}, {139, 139}, {81, 81}, {10, 50}}},
+	} {
+		wg := New(testParams(160, 160), c.shape)
+		for _, p := range c.inside {
+			if !wg.InMask(p[0], p[1]) {
+				t.Errorf("%s: cell %v is outside the mask, want inside", c.name, p)
+			}
+		}
+		for _, p := range c.outside {
+			if wg.InMask(p[0], p[1]) {
+				t.Errorf("%s: cell %v is inside the mask, want outside", c.name, p)
+			}
+		}
+		for y := range 160 {
+			for x := range 160 {
+				if got, want := wg.InMask(x, y), c.shape.Contains(float64(x), float64(y)); got != want {
+					t.Fatalf("%s: cell (%d, %d) in mask = %v, Contains = %v", c.name, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+// TestAbsorbingEdge drops a ring in the middle of a round pond, so it meets
+// the whole edge head on, and waits until it has had time to come back. With
+// reflectivity 0 almost nothing must be left of it; with 1, all of it.
+func TestAbsorbingEdge(t *testing.T) {
+	for _, c := range []struct {
+		reflectivity float64
+		min, max     float64 // share of the energy left
+	}{
+		{0, -0.02, 0.02},
+		{1, 0.99, 1.01},
+	} {
+		p := testParams(200, 200)
+		p.Reflectivity = c.reflectivity
+		wg := New(p, Circle(100, 100, 80))
+		wg.AddWave(100, 100, testBrush)
+		start := wg.Energy()
+		for range 1000 {
+			wg.Step()
+		}
+		if left := wg.Energy() / start; left < c.min || left > c.max {
+			t.Errorf("reflectivity %g: %.1f%% of the energy left, want %g%% to %g%%", c.reflectivity, 100*left, 100*c.min, 100*c.max)
+		}
+	}
+}
+
+// TestPeriodicEnergy sends a pulse across the right edge of an undamped
+// periodic grid. It must come back on the left, and the energy must hold to
+// rounding error since there are no walls to lose it to.
+func TestPeriodicEnergy(t *testing.T) {
+	// The pulse starts 5 cells from the right edge and 190 from the left, too
+	// far to reach x = 5 directly within wrapSteps
+	const steps, wrapSteps, tolerance = 4000, 100, 1e-9
+	p := testParams(200, 120)
+	p.Periodic = true
+	wg := New(p, Rect(100, 60, 202, 122))
+	wg.AddWave(195, 60, 6)
+	start := wg.Energy()
+
+	wrapped := false
+	worst := 0.0
+	for step := range steps {
+		wg.Step()
+		wrapped = wrapped || step < wrapSteps && wg.HeightAt(5, 60) != 0
+		if step%10 == 0 {
+			worst = math.Max(worst, math.Abs(wg.Energy()-start)/start)
+		}
+	}
+	if !wrapped {
+		t.Error("the pulse leaving the right edge never reached the left one")
+	}
+	if worst > tolerance {
+		t.Errorf("energy drifted by %.2e over %d steps, want at most %g", worst, steps, tolerance)
+	}
+}
+
+// Settings of TestFrontSpeed: the front is the outermost cell on the center
+// row above frontThreshold of the highest crest, found at two steps before it
+// reaches the edge of a frontPondSize pixel square.
+const (
+	frontPondSize  = 400
+	frontThreshold = 0.02
+	frontStep1     = 100
+	frontStep2     = 300
+	frontTolerance = 0.05
+)
+
+// frontPond returns an open pond frontPondSize pixels square with a click of
+// the given brush radius in the middle.
+func frontPond(p Params, brush float64) *Grid {
+	wg := New(p, Rect(frontPondSize/2, frontPondSize/2, frontPondSize+2, frontPondSize+2))
+	cx, cy := wg.Center()
+	wg.AddWave(cx, cy, brush)
+	return wg
+}
+
+// frontRadius returns how many cells from the center of wg the outermost
+// significant height is, looking along the center row for (dx, dy) = (1, 0)
+// or down the center column for (0, 1).
+func frontRadius(wg *Grid, dx, dy int) int {
+	width, height := wg.Size()
+	threshold := frontThreshold * wg.MaxHeight()
+	for r := max(width, height) / 2; r > 0; r-- {
+		x, y := width/2+r*dx, height/2+r*dy
+		if x < width && y < height && math.Abs(wg.HeightAt(x, y)) > threshold {
+			return r
+		}
+	}
+	return 0
+}
+
+// TestFrontSpeed launches a single click in the middle of an open pond and
+// checks that the radius of its wavefront grows at c·√(3/8) cells per step,
+// the long-wave speed of Step, so a change to the solver that speeds waves up
+// or slows them down shows up here.
+func TestFrontSpeed(t *testing.T) {
+	wg := frontPond(testParams(frontPondSize, frontPondSize), testBrush)
+	var r1, r2 int
+	for step := 1; step <= frontStep2; step++ {
+		wg.Step()
+		switch step {
+		case frontStep1:
+			r1 = frontRadius(wg, 1, 0)
+		case frontStep2:
+			r2 = frontRadius(wg, 1, 0)
+		}
+	}
+
+	measured := float64(r2-r1) / (frontStep2 - frontStep1)
+	expected := wg.WaveSpeed() * math.Sqrt(3.0/8.0)
+	if relErr := math.Abs(measured-expected) / expected; relErr > frontTolerance {
+		t.Errorf("wavefront radius %d cells after %d steps, %d after %d: %.4f cells/step, want %.4f (error %.2f%%)",
+			r1, frontStep1, r2, frontStep2, measured, expected, 100*relErr)
+	}
+}
+
+// anisotropicRatio is the horizontal over vertical speed
+// TestAnisotropicMedium spreads a click with.
+const anisotropicRatio = 2.0
+
+// TestAnisotropicMedium spreads a click through a medium twice as fast across
+// as down and checks that the wavefront grows into an ellipse with the same
+// aspect ratio, comparing how fast it moves along the row and the column.
+func TestAnisotropicMedium(t *testing.T) {
+	p := testParams(frontPondSize, frontPondSize)
+	p.Anisotropy = anisotropicRatio
+	wg := frontPond(p, testBrush)
+	var x1, y1, x2, y2 int
+	for step := 1; step <= frontStep2; step++ {
+		wg.Step()
+		switch step {
+		case frontStep1:
+			x1, y1 = frontRadius(wg, 1, 0), frontRadius(wg, 0, 1)
+		case frontStep2:
+			x2, y2 = frontRadius(wg, 1, 0), frontRadius(wg, 0, 1)
+		}
+	}
+
+	measured := float64(x2-x1) / float64(max(1, y2-y1))
+	if relErr := math.Abs(measured-anisotropicRatio) / anisotropicRatio; relErr > frontTolerance {
+		t.Errorf("wavefront grew %d cells across and %d down, aspect ratio %.3f, want %g (error %.2f%%)",
+			x2-x1, y2-y1, measured, anisotropicRatio, 100*relErr)
+	}
+}
+
+// TestSeparatePonds makes a wave in the left of two ponds, with a brush wide
+// enough to reach across the strip of land into the right one. The right pond
+// must stay perfectly still.
+func TestSeparatePonds(t *testing.T) {
+	wg := New(testParams(220, 120), Circle(60, 60, 45), Circle(160, 60, 45))
+	wg.AddWave(100, 60, 30)
+	for range 500 {
+		wg.Step()
+	}
+	moved := false
+	for y := range 120 {
+		for x := range 220 {
+			if h := wg.HeightAt(x, y); x > 110 && h != 0 {
+				t.Fatalf("height at (%d, %d) in the right pond = %g, want 0", x, y, h)
+			} else if x < 110 && h != 0 {
+				moved = true
+			}
+		}
+	}
+	if !moved {
+		t.Fatal("the left pond is still too, the wave was never made")
+	}
+}
+
+// TestClearSpeedRegions paints a slow circle and a slow band and clears
+// them. The whole pond must be back at the open-water speed.
+func TestClearSpeedRegions(t *testing.T) {
+	wg := New(testParams(200, 200), Circle(100, 100, 80))
+	wg.PaintSpeedCircle(70, 100, 20, 0.5)
+	wg.PaintSpeedRect(0, 120, 200, 140, 0.3)
+	wg.ClearSpeedRegions()
+	for y := range 200 {
+		for x := range 200 {
+			if s := wg.Speed(x, y); s != wg.WaveSpeed() {
+				t.Fatalf("speed at (%d, %d) = %g, want %g", x, y, s, wg.WaveSpeed())
+			}
+		}
+	}
+}
+
+// TestRefraction drives plane waves down a channel whose far half is slow
+// water at half the speed. Crossing into it, the waves must keep their
+// frequency and so halve their wavelength.
+func TestRefraction(t *testing.T) {
+	// Wrapped top to bottom, the channel has no sides and the waves stay
+	// straight, while its absorbing ends let them out
+	p := testParams(600, 40)
+	p.WaveSpeed, p.Periodic, p.Reflectivity = 1, true, 0
+	wg := New(p, Rect(300, 20, 580, 44))
+	wg.PaintSpeedRect(300, 0, 600, 40, 0.5)
+	const fastWavelength = 40.0 // cells
+	frequency := wg.WaveSpeed() * math.Sqrt(3.0/8.0) / fastWavelength
+	wg.Paddle = NewPaddle(true, 20, frequency, 5, 1) // one second per step
+	for range 1800 {
+		wg.Step()
+	}
+
+	// wavelength finds the strongest wavelength along the middle row between
+	// x0 and x1, from the peak of its windowed spectrum. Waves bouncing
+	// between the ends go both ways, so the crests aren't evenly spaced, but
+	// both directions peak at the same wavelength
+	wavelength := func(x0, x1 int) float64 {
+		best, bestPower := 0.0, 0.0
+		for l := 8.0; l <= 80; l += 0.05 {
+			var re, im float64
+			for x := x0; x < x1; x++ {
+				window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(x-x0)/float64(x1-x0))
+				h := window * wg.HeightAt(x, 20)
+				re += h * math.Cos(2*math.Pi*float64(x)/l)
+				im += h * math.Sin(2*math.Pi*float64(x)/l)
+			}
+			if power := re*re + im*im; power > bestPower {
+				best, bestPower = l, power
+			}
+		}
+		return best
+	}
+	fast, slow := wavelength(60, 280), wavelength(320, 560)
+	if math.Abs(fast-fastWavelength) > 0.05*fastWavelength {
+		t.Errorf("wavelength in open water = %.1f cells, want about %g", fast, fastWavelength)
+	}
+	if ratio := slow / fast; math.Abs(ratio-0.5) > 0.025 {
+		t.Errorf("wavelength in slow water = %.1f cells, %.3f of the open water's, want 0.5", slow, ratio)
+	}
+}